@@ -2,74 +2,80 @@ package cjsongo
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"io"
-	"sync"
+	"runtime"
 )
 
-// Unmarshal parses JSON to a map (stubbed with stdlib; expand for custom).
-func Unmarshal(data []byte) (map[string]interface{}, error) {
-	var m map[string]interface{}
-	err := json.Unmarshal(data, &m)
-	if err != nil {
-		return nil, errors.New("unmarshal error: " + err.Error())
-	}
-	return m, nil
+// Unmarshal parses any JSON value (object, array or scalar) into an Item tree.
+func Unmarshal(data []byte) (*Item, error) {
+	return unmarshal(data, ParseOptions{})
 }
 
-// UnmarshalParallel deserializes JSON objects in parallel (for large/nested data).
-func UnmarshalParallel(data []byte) (map[string]interface{}, error) {
-	// First, unmarshal top-level to map.
-	m, err := Unmarshal(data)
+func unmarshal(data []byte, opts ParseOptions) (*Item, error) {
+	p := newParser(data, opts)
+	if err := p.checkInputBytes(); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	item, err := p.parseRoot(true)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("unmarshal error: %w", err)
 	}
-	// Concurrent deserialization on sub-objects (stub: process values in parallel).
-	numWorkers := 4
-	var wg sync.WaitGroup
-	errs := make(chan error, numWorkers)
+	return item, nil
+}
 
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	chunkSize := len(keys) / numWorkers
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		start := i * chunkSize
-		end := start + chunkSize
-		if i == numWorkers-1 {
-			end = len(keys)
-		}
-		go func(keys []string) {
-			defer wg.Done()
-			for _, k := range keys {
-				// "Deserialize" sub-value (stub; real would recurse if object).
-				if _, ok := m[k].(map[string]interface{}); ok {
-					// Parallel processing placeholder.
-				}
-			}
-		}(keys[start:end])
+// UnmarshalParallel deserializes JSON in parallel (for large/nested data).
+// Large arrays and objects are scanned for their element and member
+// boundaries, big children are parsed on separate goroutines (recursively, so
+// a single huge member is split too), and the subtrees are assembled in their
+// original order. The result equals Unmarshal's; small inputs are parsed
+// sequentially.
+func UnmarshalParallel(data []byte) (*Item, error) {
+	return UnmarshalParallelWithOptions(data, ParseOptions{})
+}
+
+// UnmarshalParallelWithOptions is UnmarshalParallel within the limits in
+// opts, which apply to the whole document as for ParseWithOptions.
+// RequireEnd is implied, and opts.Arena is not used because the tree is
+// built on several goroutines.
+func UnmarshalParallelWithOptions(data []byte, opts ParseOptions) (*Item, error) {
+	opts.Arena = nil
+	procs := runtime.GOMAXPROCS(0)
+	grain := max(len(data)/(4*procs), minParallelGrain)
+	if procs == 1 || len(data) < 2*grain {
+		return unmarshal(data, opts)
 	}
-	wg.Wait()
-	select {
-	case err := <-errs:
-		return nil, err
-	default:
+	item, err := unmarshalParallel(data, grain, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
 	}
-	return m, nil
+	return item, nil
 }
 
-// Marshal serializes to JSON.
+// Marshal serializes to JSON. Items are printed in their stored order.
+// MarshalParallel produces the same output using several cores.
 func Marshal(v interface{}) ([]byte, error) {
 	return json.Marshal(v)
 }
 
-// UnmarshalStream parses from reader.
-func UnmarshalStream(r io.Reader) (map[string]interface{}, error) {
+// UnmarshalStream parses any JSON value from reader. The whole reader must
+// hold that one value; use a Decoder or DecodeStream for NDJSON and other
+// sequences of values.
+func UnmarshalStream(r io.Reader) (*Item, error) {
+	return UnmarshalStreamWithOptions(r, ParseOptions{})
+}
+
+// UnmarshalStreamWithOptions parses any JSON value from reader within the
+// limits in opts. With MaxInputBytes set no more than one byte beyond the
+// limit is read, so an oversized stream fails without being buffered whole.
+// RequireEnd is implied.
+func UnmarshalStreamWithOptions(r io.Reader, opts ParseOptions) (*Item, error) {
+	if opts.MaxInputBytes > 0 {
+		r = io.LimitReader(r, int64(opts.MaxInputBytes)+1)
+	}
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-	return Unmarshal(data)
+	return unmarshal(data, opts)
 }
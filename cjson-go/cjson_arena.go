@@ -0,0 +1,71 @@
+package cjsongo
+
+import "unsafe"
+
+// Arena allocates the items, child lists and strings of parsed documents in
+// a few large blocks instead of one allocation per node, which is what
+// cJSON_InitHooks is typically used for in C. Pass it in ParseOptions.Arena.
+//
+// Go has no explicit free: a document's blocks are released together once
+// nothing refers to any item in them, so keeping one item of a document
+// alive keeps its block alive. Arena memory is never reused, which keeps
+// trees from earlier parses valid. The zero Arena is ready to use; it may
+// serve several parses but must not be used from concurrent goroutines.
+type Arena struct {
+	items []Item
+	ptrs  []*Item
+	bytes []byte
+	next  int // Capacity of the next item block.
+}
+
+const (
+	minArenaItems = 64
+	maxArenaItems = 16 << 10
+	arenaPtrs     = 4 << 10
+	arenaBytes    = 64 << 10
+)
+
+func (a *Arena) newItem() *Item {
+	if len(a.items) == cap(a.items) {
+		a.next = min(max(2*a.next, minArenaItems), maxArenaItems)
+		a.items = make([]Item, 0, a.next)
+	}
+	a.items = a.items[:len(a.items)+1]
+	return &a.items[len(a.items)-1]
+}
+
+// children copies src into the arena. The result is capped at its length so
+// that appending to it, as AddItemToArray does, reallocates rather than
+// overwriting a neighbor.
+func (a *Arena) children(src []*Item) []*Item {
+	n := len(src)
+	if n == 0 {
+		return nil
+	}
+	if n > cap(a.ptrs)-len(a.ptrs) {
+		if n > arenaPtrs/4 {
+			return append([]*Item(nil), src...)
+		}
+		a.ptrs = make([]*Item, 0, arenaPtrs)
+	}
+	start := len(a.ptrs)
+	a.ptrs = append(a.ptrs, src...)
+	return a.ptrs[start : start+n : start+n]
+}
+
+// string copies b into the arena. Blocks are written once and never reused,
+// so the string's bytes stay immutable.
+func (a *Arena) string(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	if len(b) > cap(a.bytes)-len(a.bytes) {
+		if len(b) > arenaBytes/4 {
+			return string(b)
+		}
+		a.bytes = make([]byte, 0, arenaBytes)
+	}
+	start := len(a.bytes)
+	a.bytes = append(a.bytes, b...)
+	return unsafe.String(&a.bytes[start], len(b))
+}
@@ -0,0 +1,82 @@
+package cjsongo
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestArenaParse(t *testing.T) {
+	data := largeDocument(500)
+	want, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var arena Arena
+	got, _, err := ParseWithOptions(data, ParseOptions{RequireEnd: true, Arena: &arena})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PrintUnformatted(got) != PrintUnformatted(want) || !Compare(got, want, true) {
+		t.Fatal("arena tree differs from the per-node tree")
+	}
+
+	// Growing one child list must not overwrite its neighbor in the block.
+	other, _, err := ParseWithOptions([]byte(`[[1, 2], [3, 4]]`), ParseOptions{Arena: &arena})
+	if err != nil {
+		t.Fatal(err)
+	}
+	AddItemToArray(GetArrayItem(other, 0), CreateNumber(9))
+	if s := PrintUnformatted(other); s != `[[1,2,9],[3,4]]` {
+		t.Errorf("unexpected tree after append: %s", s)
+	}
+	if PrintUnformatted(got) != PrintUnformatted(want) {
+		t.Error("later parse in the same arena changed an earlier tree")
+	}
+}
+
+func TestArenaAllocs(t *testing.T) {
+	data := largeDocument(200)
+	perNode := testing.AllocsPerRun(10, func() {
+		if _, err := Parse(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+	arena := testing.AllocsPerRun(10, func() {
+		if _, _, err := ParseWithOptions(data, ParseOptions{Arena: new(Arena)}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if arena*20 > perNode {
+		t.Errorf("expected arena parsing to allocate far less: %v allocs vs %v per node", arena, perNode)
+	}
+}
+
+// benchmarkParse reports allocations and the GC pause time per parse.
+func benchmarkParse(b *testing.B, parse func([]byte) (*Item, error)) {
+	data := largeDocument(40000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parse(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+}
+
+func BenchmarkParseTree(b *testing.B) {
+	benchmarkParse(b, Parse)
+}
+
+func BenchmarkParseArena(b *testing.B) {
+	benchmarkParse(b, func(data []byte) (*Item, error) {
+		item, _, err := ParseWithOptions(data, ParseOptions{RequireEnd: true, Arena: new(Arena)})
+		return item, err
+	})
+}
@@ -0,0 +1,820 @@
+package cjsongo
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ItemMarshaler is implemented by types that build their own Item for
+// MarshalItem.
+type ItemMarshaler interface {
+	MarshalItem() (*Item, error)
+}
+
+// ItemUnmarshaler is implemented by types that read themselves from an Item
+// in UnmarshalItem.
+type ItemUnmarshaler interface {
+	UnmarshalItem(*Item) error
+}
+
+// BindError reports an item that cannot be stored in, or a value that cannot
+// be represented by, the Go type at Path, a JSON Pointer into the document.
+type BindError struct {
+	Path string
+	Type reflect.Type
+	Msg  string
+	Err  error // Error returned by a hook, if any.
+}
+
+func (e *BindError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("bind error at %s (Go type %v): %s", path, e.Type, e.Msg)
+}
+
+func (e *BindError) Unwrap() error { return e.Err }
+
+var (
+	itemType            = reflect.TypeFor[Item]()
+	itemMarshalerType   = reflect.TypeFor[ItemMarshaler]()
+	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// UnmarshalItem stores the document rooted at item in the value v points to,
+// following the rules of encoding/json without printing and reparsing:
+// struct fields are matched by their json tag or name (exactly, then
+// ignoring case), embedded structs are flattened, unknown members are
+// ignored and null leaves non-pointer values untouched. Types implementing
+// ItemUnmarshaler, json.Unmarshaler or encoding.TextUnmarshaler decode
+// themselves; an Item or *Item target receives a copy of the subtree.
+func UnmarshalItem(item *Item, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &BindError{Type: reflect.TypeOf(v), Msg: "target must be a non-nil pointer"}
+	}
+	b := binder{}
+	return b.decode(item, rv.Elem())
+}
+
+// MarshalItem builds an Item tree from v, following the rules of
+// encoding/json: json tags rename or omit fields, omitempty drops empty
+// values, embedded structs are flattened, map keys are sorted and []byte
+// becomes a base64 string. Types implementing ItemMarshaler, json.Marshaler
+// or encoding.TextMarshaler encode themselves; an Item or *Item is copied.
+func MarshalItem(v any) (*Item, error) {
+	b := binder{}
+	return b.encode(reflect.ValueOf(v))
+}
+
+// binder tracks the document path for error messages.
+type binder struct {
+	path []string
+}
+
+func (b *binder) errorf(t reflect.Type, format string, args ...any) error {
+	return &BindError{Path: b.pointer(), Type: t, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (b *binder) hookError(t reflect.Type, err error) error {
+	var be *BindError
+	if errors.As(err, &be) {
+		return err
+	}
+	return &BindError{Path: b.pointer(), Type: t, Msg: err.Error(), Err: err}
+}
+
+func (b *binder) pointer() string {
+	var sb strings.Builder
+	for _, p := range b.path {
+		sb.WriteByte('/')
+		sb.WriteString(pointerEscaper.Replace(p))
+	}
+	return sb.String()
+}
+
+func (b *binder) push(p string) { b.path = append(b.path, p) }
+func (b *binder) pop()          { b.path = b.path[:len(b.path)-1] }
+
+// indirect walks through pointers in v, allocating nil ones, until it
+// reaches a value that is not a pointer or whose address implements one of
+// the unmarshaling hooks, which it returns instead. When decoding null it
+// stops at the last settable pointer so that it can be set to nil.
+func indirect(v reflect.Value, null bool) (any, reflect.Value) {
+	for {
+		if v.Kind() == reflect.Interface && !v.IsNil() {
+			if e := v.Elem(); e.Kind() == reflect.Pointer && !e.IsNil() && (!null || e.Elem().Kind() == reflect.Pointer) {
+				v = e
+				continue
+			}
+		}
+		if v.CanAddr() && v.Kind() != reflect.Pointer {
+			if hook := unmarshalHook(v.Addr()); hook != nil {
+				return hook, reflect.Value{}
+			}
+		}
+		if v.Kind() != reflect.Pointer || null && v.CanSet() && v.Elem().Kind() != reflect.Pointer {
+			return nil, v
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if hook := unmarshalHook(v); hook != nil {
+			return hook, reflect.Value{}
+		}
+		v = v.Elem()
+	}
+}
+
+func unmarshalHook(v reflect.Value) any {
+	if v.Type() == reflect.PointerTo(itemType) || !v.CanInterface() {
+		return nil
+	}
+	switch hook := v.Interface().(type) {
+	case ItemUnmarshaler, json.Unmarshaler, encoding.TextUnmarshaler:
+		return hook
+	}
+	return nil
+}
+
+func (b *binder) decode(item *Item, v reflect.Value) error {
+	if item == nil {
+		return b.errorf(v.Type(), "missing item")
+	}
+	if item.Type == Raw {
+		parsed, err := Parse([]byte(item.ValueString))
+		if err != nil {
+			return b.hookError(v.Type(), err)
+		}
+		item = parsed
+	}
+	if v.Type() == itemType {
+		v.Set(reflect.ValueOf(*Duplicate(item, true)))
+		return nil
+	}
+	if v.Type() == reflect.PointerTo(itemType) {
+		if item.Type == Null {
+			v.SetZero() // Like any other pointer.
+			return nil
+		}
+		v.Set(reflect.ValueOf(Duplicate(item, true)))
+		return nil
+	}
+	hook, v := indirect(v, item.Type == Null)
+	if hook != nil {
+		var err error
+		switch hook := hook.(type) {
+		case ItemUnmarshaler:
+			err = hook.UnmarshalItem(item)
+		case json.Unmarshaler:
+			err = hook.UnmarshalJSON([]byte(PrintUnformatted(item)))
+		case encoding.TextUnmarshaler:
+			if item.Type == Null {
+				return nil
+			}
+			if item.Type != String {
+				return b.mismatch(item, reflect.TypeOf(hook))
+			}
+			err = hook.UnmarshalText([]byte(item.ValueString))
+		}
+		if err != nil {
+			return b.hookError(reflect.TypeOf(hook), err)
+		}
+		return nil
+	}
+
+	switch item.Type {
+	case Null:
+		switch v.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+			v.SetZero()
+		}
+		return nil
+	case Object:
+		return b.decodeObject(item, v)
+	case Array:
+		return b.decodeArray(item, v)
+	}
+	return b.decodeScalar(item, v)
+}
+
+func (b *binder) mismatch(item *Item, t reflect.Type) error {
+	return b.errorf(t, "cannot store %v", item.Type)
+}
+
+func (b *binder) decodeObject(item *Item, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return b.mismatch(item, v.Type())
+		}
+		v.Set(reflect.ValueOf(itemInterface(item)))
+		return nil
+	case reflect.Map:
+		return b.decodeMap(item, v)
+	case reflect.Struct:
+	default:
+		return b.mismatch(item, v.Type())
+	}
+	fields := cachedFields(v.Type())
+	for _, child := range item.Children {
+		f := fields.lookup(child.Key)
+		if f == nil {
+			continue
+		}
+		fv, err := b.fieldByIndex(v, f.index)
+		if err != nil {
+			return err
+		}
+		b.push(child.Key)
+		if f.quoted {
+			err = b.decodeQuoted(child, fv)
+		} else {
+			err = b.decode(child, fv)
+		}
+		if err != nil {
+			return err
+		}
+		b.pop()
+	}
+	return nil
+}
+
+// fieldByIndex returns the field at index, allocating nil embedded struct
+// pointers on the way.
+func (b *binder) fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, b.errorf(v.Type().Elem(), "cannot set embedded pointer to unexported struct")
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// decodeQuoted handles the ",string" tag option, which stores a number or
+// boolean as a JSON string.
+func (b *binder) decodeQuoted(item *Item, v reflect.Value) error {
+	if item.Type == Null {
+		return b.decode(item, v)
+	}
+	if item.Type != String {
+		return b.errorf(v.Type(), "cannot store %v in a string-quoted field", item.Type)
+	}
+	inner, err := Parse([]byte(item.ValueString))
+	if err != nil || inner.Type == Object || inner.Type == Array {
+		return b.errorf(v.Type(), "invalid quoted value %q", item.ValueString)
+	}
+	return b.decode(inner, v)
+}
+
+func (b *binder) decodeMap(item *Item, v reflect.Value) error {
+	t := v.Type()
+	kt := t.Key()
+	switch kt.Kind() {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !reflect.PointerTo(kt).Implements(textUnmarshalerType) {
+			return b.errorf(t, "unsupported map key type")
+		}
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(t, len(item.Children)))
+	}
+	for _, child := range item.Children {
+		b.push(child.Key)
+		key := reflect.New(kt).Elem()
+		if u, ok := key.Addr().Interface().(encoding.TextUnmarshaler); ok && kt.Kind() != reflect.String {
+			if err := u.UnmarshalText([]byte(child.Key)); err != nil {
+				return b.hookError(kt, err)
+			}
+		} else {
+			switch kt.Kind() {
+			case reflect.String:
+				key.SetString(child.Key)
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				n, err := strconv.ParseInt(child.Key, 10, 64)
+				if err != nil || key.OverflowInt(n) {
+					return b.errorf(kt, "invalid map key %q", child.Key)
+				}
+				key.SetInt(n)
+			default:
+				n, err := strconv.ParseUint(child.Key, 10, 64)
+				if err != nil || key.OverflowUint(n) {
+					return b.errorf(kt, "invalid map key %q", child.Key)
+				}
+				key.SetUint(n)
+			}
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := b.decode(child, elem); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		b.pop()
+	}
+	return nil
+}
+
+func (b *binder) decodeArray(item *Item, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return b.mismatch(item, v.Type())
+		}
+		v.Set(reflect.ValueOf(itemInterface(item)))
+		return nil
+	case reflect.Slice:
+		n := len(item.Children)
+		if v.IsNil() || v.Cap() < n {
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+		} else {
+			v.SetLen(n)
+		}
+	case reflect.Array:
+	default:
+		return b.mismatch(item, v.Type())
+	}
+	for i := 0; i < v.Len(); i++ {
+		if i >= len(item.Children) {
+			v.Index(i).SetZero()
+			continue
+		}
+		b.push(strconv.Itoa(i))
+		if err := b.decode(item.Children[i], v.Index(i)); err != nil {
+			return err
+		}
+		b.pop()
+	}
+	return nil
+}
+
+func (b *binder) decodeScalar(item *Item, v reflect.Value) error {
+	switch item.Type {
+	case True, False:
+		switch v.Kind() {
+		case reflect.Bool:
+			v.SetBool(item.Type == True)
+		case reflect.Interface:
+			if v.NumMethod() != 0 {
+				return b.mismatch(item, v.Type())
+			}
+			v.Set(reflect.ValueOf(item.Type == True))
+		default:
+			return b.mismatch(item, v.Type())
+		}
+	case String:
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(item.ValueString)
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			data, err := base64.StdEncoding.DecodeString(item.ValueString)
+			if err != nil {
+				return b.hookError(v.Type(), err)
+			}
+			v.SetBytes(data)
+		case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+			v.Set(reflect.ValueOf(item.ValueString))
+		default:
+			return b.mismatch(item, v.Type())
+		}
+	case Number:
+		return b.decodeNumber(item, v)
+	default:
+		return b.mismatch(item, v.Type())
+	}
+	return nil
+}
+
+func (b *binder) decodeNumber(item *Item, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := item.Int64()
+		if err != nil || v.OverflowInt(n) {
+			return b.errorf(v.Type(), "number %s out of range", item.NumberText())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := item.Uint64()
+		if err != nil || v.OverflowUint(n) {
+			return b.errorf(v.Type(), "number %s out of range", item.NumberText())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(item.NumberText(), v.Type().Bits())
+		if err != nil || v.OverflowFloat(f) {
+			return b.errorf(v.Type(), "number %s out of range", item.NumberText())
+		}
+		v.SetFloat(f)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return b.mismatch(item, v.Type())
+		}
+		v.Set(reflect.ValueOf(item.ValueDouble))
+	default:
+		return b.mismatch(item, v.Type())
+	}
+	return nil
+}
+
+// itemInterface converts item to the values encoding/json produces for an
+// empty interface.
+func itemInterface(item *Item) any {
+	switch item.Type {
+	case True, False:
+		return item.Type == True
+	case Number:
+		return item.ValueDouble
+	case String:
+		return item.ValueString
+	case Array:
+		s := make([]any, len(item.Children))
+		for i, child := range item.Children {
+			s[i] = itemInterface(child)
+		}
+		return s
+	case Object:
+		m := make(map[string]any, len(item.Children))
+		for _, child := range item.Children {
+			m[child.Key] = itemInterface(child)
+		}
+		return m
+	case Raw:
+		if parsed, err := Parse([]byte(item.ValueString)); err == nil {
+			return itemInterface(parsed)
+		}
+	}
+	return nil
+}
+
+func (b *binder) encode(v reflect.Value) (*Item, error) {
+	if len(b.path) > NestingLimit {
+		return nil, b.errorf(v.Type(), "nesting exceeds %d levels; is the value cyclic?", NestingLimit)
+	}
+	if !v.IsValid() {
+		return CreateNull(), nil
+	}
+	t := v.Type()
+	switch {
+	case t == itemType:
+		item := v.Interface().(Item)
+		return Duplicate(&item, true), nil
+	case t == reflect.PointerTo(itemType):
+		if v.IsNil() {
+			return CreateNull(), nil
+		}
+		return Duplicate(v.Interface().(*Item), true), nil
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return CreateNull(), nil
+	}
+	if t.Implements(itemMarshalerType) {
+		item, err := v.Interface().(ItemMarshaler).MarshalItem()
+		if err != nil {
+			return nil, b.hookError(t, err)
+		}
+		if item == nil {
+			return CreateNull(), nil
+		}
+		return item, nil
+	}
+	if t.Implements(jsonMarshalerType) {
+		data, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err == nil {
+			var item *Item
+			if item, err = Parse(data); err == nil {
+				return item, nil
+			}
+		}
+		return nil, b.hookError(t, err)
+	}
+	if t.Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, b.hookError(t, err)
+		}
+		return CreateString(string(text)), nil
+	}
+	if v.CanAddr() && t.Kind() != reflect.Pointer {
+		if pt := reflect.PointerTo(t); pt.Implements(itemMarshalerType) || pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType) {
+			return b.encode(v.Addr())
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return CreateBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		item := CreateNumber(float64(v.Int()))
+		item.setLiteral(strconv.FormatInt(v.Int(), 10))
+		return item, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		item := CreateNumber(float64(v.Uint()))
+		item.setLiteral(strconv.FormatUint(v.Uint(), 10))
+		return item, nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, b.errorf(t, "unsupported value %v", f)
+		}
+		item := CreateNumber(f)
+		item.setLiteral(strconv.FormatFloat(f, 'g', -1, t.Bits())) // Shortest text at the value's own precision.
+		return item, nil
+	case reflect.String:
+		return CreateString(v.String()), nil
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return CreateNull(), nil
+		}
+		return b.encode(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return CreateNull(), nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 && !reflect.PointerTo(t.Elem()).Implements(textMarshalerType) {
+			return CreateString(base64.StdEncoding.EncodeToString(v.Bytes())), nil
+		}
+		fallthrough
+	case reflect.Array:
+		array := &Item{Type: Array, Children: make([]*Item, v.Len())}
+		for i := range v.Len() {
+			b.push(strconv.Itoa(i))
+			child, err := b.encode(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			b.pop()
+			array.Children[i] = child
+		}
+		return array, nil
+	case reflect.Map:
+		return b.encodeMap(v)
+	case reflect.Struct:
+		return b.encodeStruct(v)
+	}
+	return nil, b.errorf(t, "unsupported type")
+}
+
+func (b *binder) encodeMap(v reflect.Value) (*Item, error) {
+	if v.IsNil() {
+		return CreateNull(), nil
+	}
+	t := v.Type()
+	type member struct {
+		key string
+		val reflect.Value
+	}
+	members := make([]member, 0, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		k := iter.Key()
+		var key string
+		switch {
+		case k.Kind() == reflect.String:
+			key = k.String()
+		case k.Type().Implements(textMarshalerType):
+			if k.Kind() == reflect.Pointer && k.IsNil() {
+				key = ""
+				break
+			}
+			text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return nil, b.hookError(t.Key(), err)
+			}
+			key = string(text)
+		case k.CanInt():
+			key = strconv.FormatInt(k.Int(), 10)
+		case k.CanUint():
+			key = strconv.FormatUint(k.Uint(), 10)
+		default:
+			return nil, b.errorf(t, "unsupported map key type")
+		}
+		members = append(members, member{key, iter.Value()})
+	}
+	slices.SortFunc(members, func(a, b member) int { return cmp.Compare(a.key, b.key) })
+	object := &Item{Type: Object, Children: make([]*Item, len(members))}
+	for i, m := range members {
+		b.push(m.key)
+		child, err := b.encode(m.val)
+		if err != nil {
+			return nil, err
+		}
+		b.pop()
+		child.Key = m.key
+		object.Children[i] = child
+	}
+	return object, nil
+}
+
+func (b *binder) encodeStruct(v reflect.Value) (*Item, error) {
+	object := CreateObject()
+fields:
+	for _, f := range cachedFields(v.Type()).list {
+		fv := v
+		for i, x := range f.index {
+			if i > 0 && fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue fields
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(x)
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		b.push(f.name)
+		child, err := b.encode(fv)
+		if err != nil {
+			return nil, err
+		}
+		b.pop()
+		if f.quoted && child.Type != Null {
+			child = CreateString(PrintUnformatted(child))
+		}
+		AddItemToObject(object, f.name, child)
+	}
+	return object, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// field is a struct field as it appears in JSON.
+type field struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+type structFields struct {
+	list   []field
+	byName map[string]int
+	byFold map[string]int
+}
+
+// lookup finds the field for a member name, exactly or else ignoring case.
+func (fs *structFields) lookup(name string) *field {
+	if i, ok := fs.byName[name]; ok {
+		return &fs.list[i]
+	}
+	if i, ok := fs.byFold[foldKey(name)]; ok {
+		return &fs.list[i]
+	}
+	return nil
+}
+
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+func cachedFields(t reflect.Type) *structFields {
+	if fs, ok := fieldCache.Load(t); ok {
+		return fs.(*structFields)
+	}
+	fs, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return fs.(*structFields)
+}
+
+// typeFields lists the JSON fields of struct type t, walking embedded
+// structs breadth first and resolving name conflicts as encoding/json does:
+// the shallowest field wins, then a tagged one, and remaining ties hide the
+// name altogether. A struct embedded along several paths at the same depth
+// conflicts with itself, so its fields are hidden too.
+func typeFields(t reflect.Type) *structFields {
+	type queued struct {
+		typ   reflect.Type
+		index []int
+	}
+	var found []field
+	current := []queued{{typ: t}}
+	visited := map[reflect.Type]bool{} // Types explored at an earlier depth.
+	count, nextCount := map[reflect.Type]int{}, map[reflect.Type]int{}
+	for len(current) > 0 {
+		var next []queued
+		count, nextCount = nextCount, map[reflect.Type]int{}
+		for _, q := range current {
+			if visited[q.typ] {
+				continue
+			}
+			visited[q.typ] = true
+			for i := range q.typ.NumField() {
+				sf := q.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(slices.Clone(q.index), i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					if nextCount[ft]++; nextCount[ft] == 1 {
+						next = append(next, queued{typ: ft, index: index})
+					}
+					continue
+				}
+				f := field{name: name, index: index, typ: sf.Type, tagged: name != ""}
+				if name == "" {
+					f.name = sf.Name
+				}
+				for _, opt := range strings.Split(opts, ",") {
+					switch opt {
+					case "omitempty":
+						f.omitEmpty = true
+					case "string":
+						switch ft.Kind() {
+						case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+							reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+							reflect.Float32, reflect.Float64, reflect.String:
+							f.quoted = true
+						}
+					}
+				}
+				found = append(found, f)
+				if count[q.typ] > 1 {
+					found = append(found, f) // A second copy makes the conflict resolution drop the name.
+				}
+			}
+		}
+		current = next
+	}
+
+	// Keep the dominant field for each name, in declaration order.
+	slices.SortStableFunc(found, func(a, b field) int {
+		return cmp.Or(cmp.Compare(a.name, b.name), cmp.Compare(len(a.index), len(b.index)))
+	})
+	var kept []field
+	for i := 0; i < len(found); {
+		j := i + 1
+		for j < len(found) && found[j].name == found[i].name && len(found[j].index) == len(found[i].index) {
+			j++
+		}
+		group := found[i:j]
+		if len(group) == 1 {
+			kept = append(kept, group[0])
+		} else if tagged := slices.IndexFunc(group, func(f field) bool { return f.tagged }); tagged >= 0 &&
+			!slices.ContainsFunc(group[tagged+1:], func(f field) bool { return f.tagged }) {
+			kept = append(kept, group[tagged])
+		}
+		for j < len(found) && found[j].name == found[i].name {
+			j++ // Deeper fields with the same name are hidden.
+		}
+		i = j
+	}
+	slices.SortFunc(kept, func(a, b field) int { return slices.Compare(a.index, b.index) })
+
+	fs := &structFields{list: kept, byName: map[string]int{}, byFold: map[string]int{}}
+	for i, f := range kept {
+		fs.byName[f.name] = i
+		if _, ok := fs.byFold[foldKey(f.name)]; !ok {
+			fs.byFold[foldKey(f.name)] = i
+		}
+	}
+	return fs
+}
@@ -0,0 +1,314 @@
+package cjsongo
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindBase struct {
+	ID      int64  `json:"id"`
+	Created string `json:"created,omitempty"`
+}
+
+type Audit struct {
+	By string
+}
+
+type celsius float64
+
+func (c celsius) MarshalItem() (*Item, error) {
+	return CreateString(fmt.Sprintf("%.1fC", float64(c))), nil
+}
+
+func (c *celsius) UnmarshalItem(item *Item) error {
+	if !IsString(item) || !strings.HasSuffix(item.ValueString, "C") {
+		return errors.New("want a temperature like 21.5C")
+	}
+	_, err := fmt.Sscanf(item.ValueString, "%fC", (*float64)(c))
+	return err
+}
+
+type bindRecord struct {
+	bindBase
+	*Audit
+	Name     string         `json:"name"`
+	Tags     []string       `json:"tags"`
+	Scores   map[string]int `json:"scores,omitempty"`
+	Nested   *bindRecord    `json:"nested,omitempty"`
+	Temp     celsius        `json:"temp"`
+	When     time.Time      `json:"when"`
+	Count    uint16         `json:"count,string"`
+	Any      any            `json:"any"`
+	Raw      *Item          `json:"raw"`
+	Data     []byte         `json:"data"`
+	ByID     map[int]bool   `json:"by_id"`
+	Skipped  string         `json:"-"`
+	Empty    []int          `json:"empty,omitempty"`
+	internal int
+}
+
+func TestBindRoundTrip(t *testing.T) {
+	when := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	in := bindRecord{
+		bindBase: bindBase{ID: 42},
+		Audit:    &Audit{By: "ops"},
+		Name:     "sensor",
+		Tags:     []string{"a", "b"},
+		Scores:   map[string]int{"z": 1, "a": 2},
+		Nested:   &bindRecord{Name: "child", Temp: -3},
+		Temp:     21.5,
+		When:     when,
+		Count:    7,
+		Any:      []any{1.5, "x", nil, map[string]any{"k": true}},
+		Raw:      CreateArray(),
+		Data:     []byte("hi"),
+		ByID:     map[int]bool{2: true, 10: false},
+		Skipped:  "never",
+		internal: 3,
+	}
+	item, err := MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":42,"By":"ops","name":"sensor","tags":["a","b"],"scores":{"a":2,"z":1},` +
+		`"nested":{"id":0,"name":"child","tags":null,"temp":"-3.0C","when":"0001-01-01T00:00:00Z","count":"0","any":null,"raw":null,"data":null,"by_id":null},` +
+		`"temp":"21.5C","when":"2024-05-06T07:08:09Z","count":"7","any":[1.5,"x",null,{"k":true}],"raw":[],"data":"aGk=","by_id":{"10":false,"2":true}}`
+	if got := PrintUnformatted(item); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+	std, _ := json.Marshal(struct {
+		Count uint16 `json:"count,string"`
+		Name  string `json:"name,string"`
+	}{7, "q"})
+	quoted, _ := MarshalItem(struct {
+		Count uint16 `json:"count,string"`
+		Name  string `json:"name,string"`
+	}{7, "q"})
+	if PrintUnformatted(quoted) != string(std) {
+		t.Errorf("string option: expected %s, got %s", std, PrintUnformatted(quoted))
+	}
+
+	var out bindRecord
+	if err := UnmarshalItem(item, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Skipped, in.internal = "", 0
+	if !Compare(out.Raw, in.Raw, true) {
+		t.Errorf("unexpected raw item %v", out.Raw)
+	}
+	out.Raw, in.Raw = nil, nil
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip mismatch:\n%+v\n%+v", out, in)
+	}
+}
+
+func TestBindLargeIntegers(t *testing.T) {
+	item, err := MarshalItem([]any{int64(1<<62 + 1), uint64(math.MaxUint64)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		I int64
+		U uint64
+	}
+	if err := UnmarshalItem(GetArrayItem(item, 0), &out.I); err != nil || out.I != 1<<62+1 {
+		t.Errorf("int64 lost precision: %d, %v", out.I, err)
+	}
+	if err := UnmarshalItem(GetArrayItem(item, 1), &out.U); err != nil || out.U != math.MaxUint64 {
+		t.Errorf("uint64 lost precision: %d, %v", out.U, err)
+	}
+}
+
+func TestUnmarshalItemRules(t *testing.T) {
+	item, err := Parse([]byte(`{"NAME": "x", "Tags": ["a"], "unknown": 1, "by": "me", "nested": null, "count": "12", "scores": {"a": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := bindRecord{Nested: &bindRecord{}, Scores: map[string]int{"keep": 5}, Name: "old"}
+	if err := UnmarshalItem(item, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "x" || len(out.Tags) != 1 || out.By != "me" || out.Nested != nil || out.Count != 12 {
+		t.Errorf("unexpected result %+v", out)
+	}
+	if out.Scores["keep"] != 5 || out.Scores["a"] != 1 {
+		t.Errorf("expected maps to be merged into, got %v", out.Scores)
+	}
+
+	var generic any
+	if err := UnmarshalItem(item, &generic); err != nil {
+		t.Fatal(err)
+	}
+	var std any
+	json.Unmarshal([]byte(PrintUnformatted(item)), &std)
+	if !reflect.DeepEqual(generic, std) {
+		t.Errorf("expected %v, got %v", std, generic)
+	}
+
+	var fixed [3]int
+	arr, _ := Parse([]byte(`[1, 2]`))
+	fixed[2] = 9
+	if err := UnmarshalItem(arr, &fixed); err != nil || fixed != [3]int{1, 2, 0} {
+		t.Errorf("unexpected array result %v, %v", fixed, err)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		into any
+		path string
+	}{
+		{`{"tags": [1]}`, new(bindRecord), "/tags/0"},
+		{`{"count": "70000"}`, new(bindRecord), "/count"},
+		{`{"temp": 3}`, new(bindRecord), "/temp"},
+		{`{"nested": {"id": 1.5}}`, new(bindRecord), "/nested/id"},
+		{`{"a/b": {"x": true}}`, new(map[string]map[string]int), "/a~1b/x"},
+		{`[300]`, new([]int8), "/0"},
+		{`"x"`, new(int), ""},
+	}
+	for _, tt := range tests {
+		item, err := Parse([]byte(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = UnmarshalItem(item, tt.into)
+		var be *BindError
+		if !errors.As(err, &be) || be.Path != tt.path {
+			t.Errorf("%s: expected bind error at %q, got %v", tt.in, tt.path, err)
+		}
+	}
+	if err := UnmarshalItem(CreateNull(), bindRecord{}); err == nil {
+		t.Error("expected an error for a non-pointer target")
+	}
+	if _, err := MarshalItem(map[string]float64{"x": math.NaN()}); err == nil {
+		t.Error("expected an error for NaN")
+	}
+	type cyclic struct{ Next *cyclic }
+	c := &cyclic{}
+	c.Next = c
+	if _, err := MarshalItem(c); err == nil {
+		t.Error("expected an error for a cyclic value")
+	}
+}
+
+func TestBindFieldConflicts(t *testing.T) {
+	type A struct{ X, Y int }
+	type B struct {
+		X int
+		Y int `json:"Y"`
+	}
+	type outer struct {
+		A
+		B
+		Z int `json:"X,omitempty"`
+	}
+	item, err := MarshalItem(outer{A: A{1, 2}, B: B{3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	std, _ := json.Marshal(outer{A: A{1, 2}, B: B{3, 4}})
+	if got := PrintUnformatted(item); got != string(std) {
+		t.Errorf("expected %s, got %s", std, got)
+	}
+}
+
+type conflictInner struct {
+	V int
+	W int `json:"w"`
+}
+
+type conflictLeft struct{ conflictInner }
+
+type conflictRight struct{ *conflictInner }
+
+func TestBindSameTypeEmbeddedTwice(t *testing.T) {
+	type outer struct {
+		conflictLeft
+		conflictRight
+		K int
+	}
+	v := outer{conflictLeft{conflictInner{1, 2}}, conflictRight{&conflictInner{3, 4}}, 5}
+	item, err := MarshalItem(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std, _ := json.Marshal(v)
+	if got := PrintUnformatted(item); got != string(std) || got != `{"K":5}` {
+		t.Errorf("expected %s, got %s", std, got)
+	}
+
+	in, err := Parse([]byte(`{"V": 7, "w": 8, "K": 9}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want outer
+	if err := UnmarshalItem(in, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{"V": 7, "w": 8, "K": 9}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if got.K != 9 || got.conflictLeft.V != 0 || got.conflictRight.conflictInner != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestBindFloat32(t *testing.T) {
+	v := struct {
+		F32 float32
+		F64 float64
+		Big float32
+	}{0.1, 0.1, 3.4e38}
+	item, err := MarshalItem(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std, _ := json.Marshal(v)
+	if got := PrintUnformatted(item); got != `{"F32":0.1,"F64":0.1,"Big":3.4e+38}` {
+		t.Errorf("expected float32 values at their own precision (encoding/json gives %s), got %s", std, got)
+	}
+}
+
+func TestBindNilMarshalerInterfaces(t *testing.T) {
+	v := struct {
+		I ItemMarshaler
+		J json.Marshaler
+		T encoding.TextMarshaler
+	}{}
+	item, err := MarshalItem(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std, _ := json.Marshal(v)
+	if got := PrintUnformatted(item); got != string(std) {
+		t.Errorf("expected %s, got %s", std, got)
+	}
+}
+
+func TestBindFoldsKeysLikeGetObjectItem(t *testing.T) {
+	var out struct {
+		Kind string `json:"kind"`
+		Size string `json:"size"`
+	}
+	item, err := Parse([]byte(`{"Kind": "kelvin", "ſize": "long s"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UnmarshalItem(item, &out); err != nil {
+		t.Fatal(err)
+	}
+	if GetObjectItem(item, "kind") == nil || GetObjectItem(item, "size") == nil {
+		t.Fatal("expected GetObjectItem to fold the keys")
+	}
+	if out.Kind != "kelvin" || out.Size != "long s" {
+		t.Errorf("expected keys folded as GetObjectItem folds them, got %+v", out)
+	}
+}
@@ -0,0 +1,293 @@
+package cjsongo
+
+import (
+	"math"
+	"slices"
+)
+
+// CreateNull returns a new null item.
+func CreateNull() *Item { return &Item{Type: Null} }
+
+// CreateTrue returns a new true item.
+func CreateTrue() *Item { return &Item{Type: True} }
+
+// CreateFalse returns a new false item.
+func CreateFalse() *Item { return &Item{Type: False} }
+
+// CreateBool returns a new true or false item.
+func CreateBool(b bool) *Item {
+	if b {
+		return CreateTrue()
+	}
+	return CreateFalse()
+}
+
+// CreateNumber returns a new number item.
+func CreateNumber(f float64) *Item { return &Item{Type: Number, ValueDouble: f} }
+
+// CreateString returns a new string item.
+func CreateString(s string) *Item { return &Item{Type: String, ValueString: s} }
+
+// CreateRaw returns an item whose text is written verbatim when printed. The
+// caller is responsible for raw being valid JSON.
+func CreateRaw(raw string) *Item { return &Item{Type: Raw, ValueString: raw} }
+
+// CreateArray returns a new empty array.
+func CreateArray() *Item { return &Item{Type: Array} }
+
+// CreateObject returns a new empty object.
+func CreateObject() *Item { return &Item{Type: Object} }
+
+// AddItemToArray appends item to array. Like cJSON, an item must not be
+// added to more than one parent; use Duplicate to copy it first.
+func AddItemToArray(array, item *Item) bool {
+	if array == nil || item == nil || array == item {
+		return false
+	}
+	array.Children = append(array.Children, item)
+	return true
+}
+
+// AddItemToObject appends item to object under key. Existing members with
+// the same key are kept, as in cJSON.
+func AddItemToObject(object *Item, key string, item *Item) bool {
+	if object == nil || item == nil || object == item {
+		return false
+	}
+	item.Key = key
+	object.Children = append(object.Children, item)
+	return true
+}
+
+// AddNullToObject adds a null member and returns it.
+func AddNullToObject(object *Item, key string) *Item {
+	return addToObject(object, key, CreateNull())
+}
+
+// AddTrueToObject adds a true member and returns it.
+func AddTrueToObject(object *Item, key string) *Item {
+	return addToObject(object, key, CreateTrue())
+}
+
+// AddFalseToObject adds a false member and returns it.
+func AddFalseToObject(object *Item, key string) *Item {
+	return addToObject(object, key, CreateFalse())
+}
+
+// AddBoolToObject adds a boolean member and returns it.
+func AddBoolToObject(object *Item, key string, b bool) *Item {
+	return addToObject(object, key, CreateBool(b))
+}
+
+// AddNumberToObject adds a number member and returns it.
+func AddNumberToObject(object *Item, key string, f float64) *Item {
+	return addToObject(object, key, CreateNumber(f))
+}
+
+// AddStringToObject adds a string member and returns it.
+func AddStringToObject(object *Item, key, s string) *Item {
+	return addToObject(object, key, CreateString(s))
+}
+
+// AddRawToObject adds a raw member and returns it.
+func AddRawToObject(object *Item, key, raw string) *Item {
+	return addToObject(object, key, CreateRaw(raw))
+}
+
+// AddObjectToObject adds an empty object member and returns it.
+func AddObjectToObject(object *Item, key string) *Item {
+	return addToObject(object, key, CreateObject())
+}
+
+// AddArrayToObject adds an empty array member and returns it.
+func AddArrayToObject(object *Item, key string) *Item {
+	return addToObject(object, key, CreateArray())
+}
+
+func addToObject(object *Item, key string, item *Item) *Item {
+	if !AddItemToObject(object, key, item) {
+		return nil
+	}
+	return item
+}
+
+// InsertItemInArray inserts item before index which, shifting later elements
+// up. An index past the end appends.
+func InsertItemInArray(array *Item, which int, item *Item) bool {
+	if array == nil || item == nil || array == item || which < 0 {
+		return false
+	}
+	if which >= len(array.Children) {
+		return AddItemToArray(array, item)
+	}
+	array.Children = slices.Insert(array.Children, which, item)
+	return true
+}
+
+// DetachItemViaPointer removes item from parent and returns it, or nil if
+// item is not a child of parent.
+func DetachItemViaPointer(parent, item *Item) *Item {
+	if parent == nil || item == nil {
+		return nil
+	}
+	i := slices.Index(parent.Children, item)
+	if i < 0 {
+		return nil
+	}
+	parent.Children = slices.Delete(parent.Children, i, i+1)
+	return item
+}
+
+// DetachItemFromArray removes and returns the element at index which.
+func DetachItemFromArray(array *Item, which int) *Item {
+	return DetachItemViaPointer(array, GetArrayItem(array, which))
+}
+
+// DetachItemFromObject removes and returns the first member matching key,
+// ignoring case.
+func DetachItemFromObject(object *Item, key string) *Item {
+	return DetachItemViaPointer(object, GetObjectItem(object, key))
+}
+
+// DetachItemFromObjectCaseSensitive removes and returns the first member
+// named exactly key.
+func DetachItemFromObjectCaseSensitive(object *Item, key string) *Item {
+	return DetachItemViaPointer(object, GetObjectItemCaseSensitive(object, key))
+}
+
+// DeleteItemFromArray removes the element at index which. The garbage
+// collector takes the place of cJSON_Delete.
+func DeleteItemFromArray(array *Item, which int) {
+	DetachItemFromArray(array, which)
+}
+
+// DeleteItemFromObject removes the first member matching key, ignoring case.
+func DeleteItemFromObject(object *Item, key string) {
+	DetachItemFromObject(object, key)
+}
+
+// DeleteItemFromObjectCaseSensitive removes the first member named exactly key.
+func DeleteItemFromObjectCaseSensitive(object *Item, key string) {
+	DetachItemFromObjectCaseSensitive(object, key)
+}
+
+// ReplaceItemViaPointer puts replacement in item's place within parent. The
+// replacement takes over item's key.
+func ReplaceItemViaPointer(parent, item, replacement *Item) bool {
+	if parent == nil || item == nil || replacement == nil {
+		return false
+	}
+	i := slices.Index(parent.Children, item)
+	if i < 0 {
+		return false
+	}
+	if replacement != item {
+		replacement.Key = item.Key
+		parent.Children[i] = replacement
+	}
+	return true
+}
+
+// ReplaceItemInArray replaces the element at index which.
+func ReplaceItemInArray(array *Item, which int, newItem *Item) bool {
+	return ReplaceItemViaPointer(array, GetArrayItem(array, which), newItem)
+}
+
+// ReplaceItemInObject replaces the first member matching key, ignoring case.
+// The new member is named key.
+func ReplaceItemInObject(object *Item, key string, newItem *Item) bool {
+	if !ReplaceItemViaPointer(object, GetObjectItem(object, key), newItem) {
+		return false
+	}
+	newItem.Key = key
+	return true
+}
+
+// ReplaceItemInObjectCaseSensitive replaces the first member named exactly key.
+func ReplaceItemInObjectCaseSensitive(object *Item, key string, newItem *Item) bool {
+	return ReplaceItemViaPointer(object, GetObjectItemCaseSensitive(object, key), newItem)
+}
+
+// Duplicate copies item. With recurse the whole subtree is copied; without
+// it, as in cJSON, the copy has no children.
+func Duplicate(item *Item, recurse bool) *Item {
+	if item == nil {
+		return nil
+	}
+	dup := *item
+	dup.Children = nil
+	if recurse && len(item.Children) > 0 {
+		dup.Children = make([]*Item, len(item.Children))
+		for i, child := range item.Children {
+			dup.Children[i] = Duplicate(child, true)
+		}
+	}
+	return &dup
+}
+
+// Compare reports whether a and b hold equal JSON values. Object members are
+// matched by key regardless of order, with case sensitivity as requested;
+// numbers are compared with a relative tolerance, like cJSON_Compare.
+func Compare(a, b *Item, caseSensitive bool) bool {
+	if a == nil || b == nil || a.Type != b.Type || a.Type == Invalid {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	switch a.Type {
+	case False, True, Null:
+		return true
+	case Number:
+		return compareDouble(a.ValueDouble, b.ValueDouble)
+	case String, Raw:
+		return a.ValueString == b.ValueString
+	case Array:
+		if len(a.Children) != len(b.Children) {
+			return false
+		}
+		for i := range a.Children {
+			if !Compare(a.Children[i], b.Children[i], caseSensitive) {
+				return false
+			}
+		}
+		return true
+	case Object:
+		return compareObjects(a, b, caseSensitive)
+	}
+	return false
+}
+
+// compareObjects matches the members of a and b by key. Repeated keys are
+// paired in order of appearance, so a document with duplicate members equals
+// its copy.
+func compareObjects(a, b *Item, caseSensitive bool) bool {
+	if len(a.Children) != len(b.Children) {
+		return false
+	}
+	fold := func(key string) string {
+		if caseSensitive {
+			return key
+		}
+		return foldKey(key) // The rule GetObjectItem applies.
+	}
+	members := make(map[string][]*Item, len(b.Children))
+	for _, child := range b.Children {
+		key := fold(child.Key)
+		members[key] = append(members[key], child)
+	}
+	for _, child := range a.Children {
+		key := fold(child.Key)
+		same := members[key]
+		if len(same) == 0 || !Compare(child, same[0], caseSensitive) {
+			return false
+		}
+		members[key] = same[1:]
+	}
+	return true
+}
+
+func compareDouble(a, b float64) bool {
+	maxVal := math.Max(math.Abs(a), math.Abs(b))
+	return math.Abs(a-b) <= maxVal*2.220446049250313e-16 // DBL_EPSILON
+}
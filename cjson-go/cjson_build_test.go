@@ -0,0 +1,166 @@
+package cjsongo
+
+import "testing"
+
+func TestBuildTree(t *testing.T) {
+	root := CreateObject()
+	AddStringToObject(root, "name", "jack")
+	AddNumberToObject(root, "age", 42)
+	AddBoolToObject(root, "admin", false)
+	AddNullToObject(root, "spouse")
+	tags := AddArrayToObject(root, "tags")
+	AddItemToArray(tags, CreateString("a"))
+	AddItemToArray(tags, CreateString("c"))
+	if !InsertItemInArray(tags, 1, CreateString("b")) || !InsertItemInArray(tags, 10, CreateString("d")) {
+		t.Fatal("insert failed")
+	}
+	AddRawToObject(AddObjectToObject(root, "extra"), "raw", `[1,2]`)
+
+	want := `{"name":"jack","age":42,"admin":false,"spouse":null,"tags":["a","b","c","d"],"extra":{"raw":[1,2]}}`
+	if got := PrintUnformatted(root); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if AddItemToArray(tags, tags) || AddItemToArray(nil, CreateNull()) || InsertItemInArray(tags, -1, CreateNull()) {
+		t.Error("expected invalid additions to be rejected")
+	}
+}
+
+func TestDetachDeleteReplace(t *testing.T) {
+	root, err := Parse([]byte(`{"a": 1, "B": 2, "b": 3, "list": [0, 1, 2, 3]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := DetachItemFromObjectCaseSensitive(root, "b"); GetNumberValue(d) != 3 {
+		t.Errorf("expected to detach b=3, got %v", d)
+	}
+	if d := DetachItemFromObject(root, "b"); GetNumberValue(d) != 2 || d.Key != "B" {
+		t.Errorf("expected to detach B=2, got %v", d)
+	}
+	if DetachItemFromObject(root, "missing") != nil {
+		t.Error("expected nil for missing key")
+	}
+	list := GetObjectItem(root, "list")
+	if d := DetachItemFromArray(list, 1); GetNumberValue(d) != 1 {
+		t.Errorf("unexpected detached element %v", d)
+	}
+	DeleteItemFromArray(list, 0)
+	if !ReplaceItemInArray(list, 1, CreateString("three")) || ReplaceItemInArray(list, 5, CreateNull()) {
+		t.Error("unexpected ReplaceItemInArray result")
+	}
+	if !ReplaceItemInObject(root, "A", CreateTrue()) {
+		t.Error("ReplaceItemInObject failed")
+	}
+	DeleteItemFromObjectCaseSensitive(root, "LIST")
+	want := `{"A":true,"list":[2,"three"]}`
+	if got := PrintUnformatted(root); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	DeleteItemFromObject(root, "LIST")
+	if got := PrintUnformatted(root); got != `{"A":true}` {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestDuplicateCompare(t *testing.T) {
+	a, err := Parse([]byte(`{"x": [1, {"y": "z"}], "n": 0.1, "t": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deep := Duplicate(a, true)
+	if !Compare(a, deep, true) {
+		t.Fatal("deep duplicate should compare equal")
+	}
+	GetObjectItem(GetArrayItem(GetObjectItem(deep, "x"), 1), "y").ValueString = "changed"
+	if GetStringValue(GetObjectItem(GetArrayItem(GetObjectItem(a, "x"), 1), "y")) != "z" {
+		t.Error("deep duplicate shares nodes with the original")
+	}
+	if Compare(a, deep, true) {
+		t.Error("modified duplicate should differ")
+	}
+	if shallow := Duplicate(a, false); !IsObject(shallow) || GetArraySize(shallow) != 0 {
+		t.Error("shallow duplicate should have no children")
+	}
+
+	b, err := Parse([]byte(`{"T": true, "N": 0.1, "X": [1, {"Y": "z"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Compare(a, b, false) {
+		t.Error("expected case-insensitive, order-insensitive match")
+	}
+	if Compare(a, b, true) {
+		t.Error("expected case-sensitive mismatch")
+	}
+	tests := []struct {
+		a, b  *Item
+		equal bool
+	}{
+		{CreateNumber(0.1 + 0.2), CreateNumber(0.3), true},
+		{CreateNumber(1), CreateNumber(1.0001), false},
+		{CreateString("a"), CreateString("a"), true},
+		{CreateTrue(), CreateFalse(), false},
+		{CreateArray(), CreateObject(), false},
+		{CreateNull(), nil, false},
+	}
+	for i, tt := range tests {
+		if got := Compare(tt.a, tt.b, true); got != tt.equal {
+			t.Errorf("case %d: expected %v, got %v", i, tt.equal, got)
+		}
+	}
+}
+
+func TestCompareDuplicateKeys(t *testing.T) {
+	parse := func(s string) *Item {
+		t.Helper()
+		item, err := Parse([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return item
+	}
+	a := parse(`{"k": 1, "x": true, "K": 2}`)
+	if !Compare(a, Duplicate(a, true), true) {
+		t.Error("object with repeated keys should equal its copy")
+	}
+	b := parse(`{"x": true, "k": 1, "K": 2}`)
+	if !Compare(a, b, false) || !Compare(a, b, true) {
+		t.Error("expected reordered members to compare equal")
+	}
+	c := parse(`{"K": 2, "x": true, "k": 1}`)
+	if Compare(a, c, false) || !Compare(a, c, true) {
+		t.Error("case-insensitive repeats should pair in order of appearance")
+	}
+	d := parse(`{"k": 1, "x": true}`)
+	if Compare(a, d, true) || Compare(d, a, true) {
+		t.Error("objects of different sizes should differ")
+	}
+}
+
+func TestCompareFoldsKeysLikeGetObjectItem(t *testing.T) {
+	for _, tt := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{"\u212a", "k", true}, // Kelvin sign.
+		{"\u017f", "S", true}, // Long s.
+		{"\u03a3", "\u03c2", true},
+		{"ǅ", "ǆ", true},
+		{"straße", "STRASSE", false},
+		{"a", "b", false},
+	} {
+		a, err := Parse([]byte(`{"` + tt.a + `": 1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Parse([]byte(`{"` + tt.b + `": 1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Compare(a, b, false); got != tt.equal {
+			t.Errorf("%q vs %q: expected Compare %v, got %v", tt.a, tt.b, tt.equal, got)
+		}
+		if found := GetObjectItem(a, GetArrayItem(b, 0).Key) != nil; found != tt.equal {
+			t.Errorf("%q vs %q: expected GetObjectItem to agree with Compare", tt.a, tt.b)
+		}
+	}
+}
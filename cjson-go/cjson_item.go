@@ -0,0 +1,165 @@
+package cjsongo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Type identifies the kind of value an Item holds, like cJSON's type flags.
+type Type int
+
+const (
+	// Invalid is the zero Type; no parsed item has it.
+	Invalid Type = iota
+	// False is the JSON literal false.
+	False
+	// True is the JSON literal true.
+	True
+	// Null is the JSON literal null.
+	Null
+	// Number is a JSON number.
+	Number
+	// String is a JSON string.
+	String
+	// Array is a JSON array.
+	Array
+	// Object is a JSON object.
+	Object
+	// Raw holds preformatted JSON text in ValueString, emitted verbatim.
+	Raw
+)
+
+var typeNames = [...]string{"invalid", "false", "true", "null", "number", "string", "array", "object", "raw"}
+
+func (t Type) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return "unknown"
+	}
+	return typeNames[t]
+}
+
+// Item is one node of a JSON document, modeled after the cJSON struct. Where
+// cJSON links siblings through next/prev pointers, an Item keeps its array
+// elements or object members in Children, in document order. Object members
+// carry their name in Key; duplicate names are kept as separate members.
+type Item struct {
+	Type        Type
+	Key         string  // Member name when the item belongs to an object (cJSON's "string").
+	ValueString string  // Value of a String, or the text of a Raw item.
+	ValueDouble float64 // Value of a Number.
+	Children    []*Item // Elements of an Array or members of an Object.
+
+	numText   string  // Number literal as written in the input.
+	numDouble float64 // ValueDouble when numText was recorded, to detect changes.
+}
+
+// GetArraySize returns the number of elements or members of item.
+func GetArraySize(array *Item) int {
+	if array == nil {
+		return 0
+	}
+	return len(array.Children)
+}
+
+// GetArrayItem returns the element at index, or nil when out of range.
+func GetArrayItem(array *Item, index int) *Item {
+	if array == nil || index < 0 || index >= len(array.Children) {
+		return nil
+	}
+	return array.Children[index]
+}
+
+// GetObjectItem returns the first member of object whose name matches key,
+// ignoring case like cJSON_GetObjectItem. It returns nil when none matches.
+func GetObjectItem(object *Item, key string) *Item {
+	if object == nil {
+		return nil
+	}
+	for _, child := range object.Children {
+		if strings.EqualFold(child.Key, key) {
+			return child
+		}
+	}
+	return nil
+}
+
+// foldKey maps key to one representative of its case-folding class, so that
+// foldKey(a) == foldKey(b) exactly when strings.EqualFold(a, b).
+func foldKey(key string) string {
+	var b strings.Builder
+	b.Grow(len(key))
+	for _, r := range key {
+		lowest := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			lowest = min(lowest, f)
+		}
+		b.WriteRune(lowest)
+	}
+	return b.String()
+}
+
+// GetObjectItemCaseSensitive returns the first member of object named
+// exactly key, or nil.
+func GetObjectItemCaseSensitive(object *Item, key string) *Item {
+	if object == nil {
+		return nil
+	}
+	for _, child := range object.Children {
+		if child.Key == key {
+			return child
+		}
+	}
+	return nil
+}
+
+// HasObjectItem reports whether GetObjectItem finds key in object.
+func HasObjectItem(object *Item, key string) bool {
+	return GetObjectItem(object, key) != nil
+}
+
+// GetStringValue returns the value of a String item, or "" for other items.
+func GetStringValue(item *Item) string {
+	if !IsString(item) {
+		return ""
+	}
+	return item.ValueString
+}
+
+// GetNumberValue returns the value of a Number item, or 0 for other items.
+func GetNumberValue(item *Item) float64 {
+	if !IsNumber(item) {
+		return 0
+	}
+	return item.ValueDouble
+}
+
+// IsInvalid reports whether item has the Invalid type. It is false for nil,
+// as with all predicates.
+func IsInvalid(item *Item) bool { return item != nil && item.Type == Invalid }
+
+// IsFalse reports whether item is the literal false.
+func IsFalse(item *Item) bool { return item != nil && item.Type == False }
+
+// IsTrue reports whether item is the literal true.
+func IsTrue(item *Item) bool { return item != nil && item.Type == True }
+
+// IsBool reports whether item is true or false.
+func IsBool(item *Item) bool { return IsTrue(item) || IsFalse(item) }
+
+// IsNull reports whether item is the literal null.
+func IsNull(item *Item) bool { return item != nil && item.Type == Null }
+
+// IsNumber reports whether item is a number.
+func IsNumber(item *Item) bool { return item != nil && item.Type == Number }
+
+// IsString reports whether item is a string.
+func IsString(item *Item) bool { return item != nil && item.Type == String }
+
+// IsArray reports whether item is an array.
+func IsArray(item *Item) bool { return item != nil && item.Type == Array }
+
+// IsObject reports whether item is an object.
+func IsObject(item *Item) bool { return item != nil && item.Type == Object }
+
+// IsRaw reports whether item holds raw JSON text.
+func IsRaw(item *Item) bool { return item != nil && item.Type == Raw }
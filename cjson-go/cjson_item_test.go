@@ -0,0 +1,146 @@
+package cjsongo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseItemTree(t *testing.T) {
+	data := []byte(`{"b": 1, "a": [true, false, null, "sé😀", -2.5e1], "B": {"dup": 1, "dup": 2}, "n": 12345678901234567890}`)
+	root, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsObject(root) || GetArraySize(root) != 4 {
+		t.Fatalf("expected object with 4 members, got %v with %d", root.Type, GetArraySize(root))
+	}
+	keys := []string{"b", "a", "B", "n"}
+	for i, child := range root.Children {
+		if child.Key != keys[i] {
+			t.Errorf("member %d: expected key %q, got %q", i, keys[i], child.Key)
+		}
+	}
+	if GetObjectItem(root, "B") != root.Children[0] {
+		t.Error("GetObjectItem should match case-insensitively and return the first member")
+	}
+	if GetObjectItemCaseSensitive(root, "B") != root.Children[2] {
+		t.Error("GetObjectItemCaseSensitive should match exactly")
+	}
+	arr := GetObjectItem(root, "a")
+	if !IsTrue(GetArrayItem(arr, 0)) || !IsFalse(GetArrayItem(arr, 1)) || !IsNull(GetArrayItem(arr, 2)) || !IsBool(GetArrayItem(arr, 0)) {
+		t.Error("unexpected literal types")
+	}
+	if s := GetStringValue(GetArrayItem(arr, 3)); s != "sé😀" {
+		t.Errorf("unexpected string %q", s)
+	}
+	if n := GetNumberValue(GetArrayItem(arr, 4)); n != -25 {
+		t.Errorf("unexpected number %v", n)
+	}
+	if GetArrayItem(arr, 5) != nil || GetArrayItem(arr, -1) != nil || GetArrayItem(nil, 0) != nil {
+		t.Error("expected nil for out of range index")
+	}
+	if GetArraySize(root.Children[2]) != 2 {
+		t.Error("duplicate keys should be kept")
+	}
+	if big := GetObjectItem(root, "n"); big.numText != "12345678901234567890" {
+		t.Errorf("number literal not kept: %q", big.numText)
+	}
+	if IsObject(nil) || IsInvalid(nil) || HasObjectItem(root, "missing") {
+		t.Error("predicates should be false for nil and missing items")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		``, `{`, `{"a" 1}`, `{"a": 1,}`, `[1 2]`, `[1,]`, `01`, `1.`, `-`, `1e`, `tru`, `"abc`,
+		"\"a\tb\"", `"\x"`, `"\u12"`, `{1: 2}`, `{} {}`, `nul`,
+	} {
+		if _, err := Parse([]byte(in)); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	data := []byte(` {"a": 1} [2, 3]  "four" 5` + "\n")
+	var got []string
+	var ends []int
+	rest, consumed := data, 0
+	for {
+		item, n, err := ParseWithOptions(rest, ParseOptions{})
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, PrintUnformatted(item))
+		consumed += n
+		ends = append(ends, consumed)
+		rest = rest[n:]
+	}
+	want := []string{`{"a":1}`, `[2,3]`, `"four"`, `5`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if wantEnds := []int{9, 16, 24, 26}; !reflect.DeepEqual(ends, wantEnds) {
+		t.Errorf("expected end offsets %v, got %v", wantEnds, ends)
+	}
+
+	if _, n, err := ParseWithOptions([]byte(`[1] x`), ParseOptions{RequireEnd: true}); err == nil || n != 4 {
+		t.Errorf("expected trailing data error at 4, got %d, %v", n, err)
+	}
+	if item, n, err := ParseWithOptions([]byte("[1] \n"), ParseOptions{RequireEnd: true}); err != nil || n != 5 || GetArraySize(item) != 1 {
+		t.Errorf("unexpected result %v, %d, %v", item, n, err)
+	}
+	if _, n, err := ParseWithOptions([]byte(`[1, x]`), ParseOptions{}); err == nil || n != 4 {
+		t.Errorf("expected syntax error at 4, got %d, %v", n, err)
+	}
+	if _, _, err := ParseWithOptions([]byte(" \t"), ParseOptions{RequireEnd: true}); err != io.EOF {
+		t.Errorf("expected io.EOF for blank input, got %v", err)
+	}
+}
+
+func TestParseErrorLocation(t *testing.T) {
+	data := []byte("{\n  \"a\": [1, 2],\n  \"b\": tru\n}")
+	_, err := Parse(data)
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *ParseError, got %T", err)
+	}
+	if pe.Offset != 24 || pe.Line != 3 || pe.Column != 8 {
+		t.Errorf("expected offset 24 at 3:8, got %d at %d:%d", pe.Offset, pe.Line, pe.Column)
+	}
+	if pe.Expected != "a value" || !strings.Contains(pe.Snippet, `"b": tru`) {
+		t.Errorf("unexpected expectation %q or snippet %q", pe.Expected, pe.Snippet)
+	}
+	if want := "parse error at line 3, column 8 (offset 24): invalid character 't', expected a value"; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+
+	bad := []byte(`[1, 2`)
+	entries := map[string]func() error{
+		"ParseWithOptions": func() error { _, _, err := ParseWithOptions(bad, ParseOptions{}); return err },
+		"Unmarshal":        func() error { _, err := Unmarshal(bad); return err },
+		"UnmarshalStream":  func() error { _, err := UnmarshalStream(bytes.NewReader(bad)); return err },
+		"UnmarshalParallel": func() error {
+			_, err := unmarshalParallel([]byte(`[[1, 2], [3, 4], [5, x]]`), 1, ParseOptions{})
+			return err
+		},
+	}
+	for name, fn := range entries {
+		if err := fn(); !errors.As(err, &pe) {
+			t.Errorf("%s: expected *ParseError, got %v", name, err)
+		}
+	}
+	if _, _, err := ParseWithOptions(bad, ParseOptions{}); errors.As(err, &pe) && pe.Expected != "',' or ']'" {
+		t.Errorf("unexpected expectation %q", pe.Expected)
+	}
+	if _, err := unmarshalParallel([]byte(`[[1, 2], [3, 4], [5, x]]`), 1, ParseOptions{}); errors.As(err, &pe) && (pe.Offset != 21 || pe.Snippet != `2], [3, 4], [5, x]]`) {
+		t.Errorf("unexpected parallel error offset %d, snippet %q", pe.Offset, pe.Snippet)
+	}
+}
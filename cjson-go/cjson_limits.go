@@ -0,0 +1,84 @@
+package cjsongo
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// NestingLimit is the default maximum nesting depth of arrays and objects,
+// matching CJSON_NESTING_LIMIT.
+const NestingLimit = 1000
+
+// Errors wrapped by ParseError when a ParseOptions limit is exceeded.
+var (
+	ErrMaxDepth        = errors.New("nesting depth limit exceeded")
+	ErrMaxInputBytes   = errors.New("input size limit exceeded")
+	ErrMaxItems        = errors.New("item count limit exceeded")
+	ErrMaxStringLength = errors.New("string length limit exceeded")
+)
+
+// limits holds the ParseOptions limits in effect for a parser.
+type limits struct {
+	maxDepth  int // Negative for no limit.
+	maxInput  int
+	maxItems  int64
+	maxString int
+	items     *atomic.Int64 // Items created so far; nil without MaxItems. Shared by parallel parsers.
+}
+
+func newLimits(opts ParseOptions) limits {
+	l := limits{maxDepth: opts.MaxDepth, maxInput: opts.MaxInputBytes, maxItems: int64(opts.MaxItems), maxString: opts.MaxStringLength}
+	if l.maxDepth == 0 {
+		l.maxDepth = NestingLimit
+	}
+	if l.maxItems > 0 {
+		l.items = new(atomic.Int64)
+	}
+	return l
+}
+
+func newParser(data []byte, opts ParseOptions) *parser {
+	return &parser{data: data, arena: opts.Arena, limits: newLimits(opts)}
+}
+
+// limitError reports an exceeded limit at offset off.
+func (p *parser) limitError(off int, err error) error {
+	p.pos = off
+	e := p.newError("", err.Error())
+	e.Err = err
+	return e
+}
+
+func (p *parser) checkInputBytes() error {
+	if p.maxInput > 0 && len(p.data) > p.maxInput {
+		return p.limitError(p.maxInput, ErrMaxInputBytes)
+	}
+	return nil
+}
+
+// enter records the opening of a container.
+func (p *parser) enter() error {
+	p.depth++
+	if p.maxDepth >= 0 && p.depth > p.maxDepth {
+		p.depth--
+		return p.limitError(p.pos, ErrMaxDepth)
+	}
+	return nil
+}
+
+// countItem records the creation of an item.
+func (p *parser) countItem() error {
+	if p.items != nil && p.items.Add(1) > p.maxItems {
+		return p.limitError(p.pos, ErrMaxItems)
+	}
+	return nil
+}
+
+// checkString checks the decoded length n of the string whose contents
+// start at start.
+func (p *parser) checkString(start, n int) error {
+	if p.maxString > 0 && n > p.maxString {
+		return p.limitError(start-1, ErrMaxStringLength)
+	}
+	return nil
+}
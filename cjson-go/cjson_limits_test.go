@@ -0,0 +1,118 @@
+package cjsongo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLimits(t *testing.T) {
+	tests := []struct {
+		in   string
+		opts ParseOptions
+		want error
+		off  int
+	}{
+		{`[[[1]]]`, ParseOptions{MaxDepth: 2}, ErrMaxDepth, 2},
+		{`[[[1]]]`, ParseOptions{MaxDepth: 3}, nil, 0},
+		{`{"a": {"b": 1}}`, ParseOptions{MaxDepth: 1}, ErrMaxDepth, 6},
+		{`[1, 2, 3]`, ParseOptions{MaxInputBytes: 8}, ErrMaxInputBytes, 8},
+		{`[1, 2, 3]`, ParseOptions{MaxInputBytes: 9}, nil, 0},
+		{`[1, 2, 3]`, ParseOptions{MaxItems: 3}, ErrMaxItems, 7},
+		{`[1, 2, 3]`, ParseOptions{MaxItems: 4}, nil, 0},
+		{`["abc", "abcd"]`, ParseOptions{MaxStringLength: 3}, ErrMaxStringLength, 8},
+		{`{"abcd": 1}`, ParseOptions{MaxStringLength: 3}, ErrMaxStringLength, 1},
+		{`["aé\n"]`, ParseOptions{MaxStringLength: 4}, nil, 0},
+		{`["aé\n!"]`, ParseOptions{MaxStringLength: 4}, ErrMaxStringLength, 1},
+	}
+	for _, tt := range tests {
+		_, _, err := ParseWithOptions([]byte(tt.in), tt.opts)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s %+v: expected %v, got %v", tt.in, tt.opts, tt.want, err)
+			continue
+		}
+		var pe *ParseError
+		if tt.want != nil && (!errors.As(err, &pe) || pe.Offset != tt.off) {
+			t.Errorf("%s %+v: expected error at offset %d, got %v", tt.in, tt.opts, tt.off, err)
+		}
+	}
+}
+
+func TestDefaultNestingLimit(t *testing.T) {
+	nested := func(depth int) []byte {
+		return []byte(strings.Repeat("[", depth) + strings.Repeat("]", depth))
+	}
+	if _, err := Parse(nested(NestingLimit)); err != nil {
+		t.Errorf("expected %d levels to parse, got %v", NestingLimit, err)
+	}
+	if _, err := Unmarshal(nested(NestingLimit + 1)); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("expected ErrMaxDepth, got %v", err)
+	}
+	if _, _, err := ParseWithOptions(nested(NestingLimit+1), ParseOptions{MaxDepth: -1}); err != nil {
+		t.Errorf("expected negative MaxDepth to disable the limit, got %v", err)
+	}
+}
+
+// countingReader counts the bytes read from an endless stream of spaces.
+type countingReader struct{ n int }
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = ' '
+	}
+	r.n += len(b)
+	return len(b), nil
+}
+
+func TestUnmarshalStreamLimits(t *testing.T) {
+	r := &countingReader{}
+	if _, err := UnmarshalStreamWithOptions(r, ParseOptions{MaxInputBytes: 1000}); !errors.Is(err, ErrMaxInputBytes) {
+		t.Fatalf("expected ErrMaxInputBytes, got %v", err)
+	}
+	if r.n > 1<<16 {
+		t.Errorf("read %d bytes from an unbounded stream", r.n)
+	}
+	if _, err := UnmarshalStreamWithOptions(strings.NewReader(`{"a": [1, 2]}`), ParseOptions{MaxItems: 3}); !errors.Is(err, ErrMaxItems) {
+		t.Errorf("expected ErrMaxItems, got %v", err)
+	}
+	if item, err := UnmarshalStreamWithOptions(strings.NewReader(`{"a": [1, 2]}`), ParseOptions{MaxInputBytes: 13, MaxItems: 4}); err != nil || GetArraySize(GetObjectItem(item, "a")) != 2 {
+		t.Errorf("unexpected result %v, %v", item, err)
+	}
+}
+
+func TestParallelLimits(t *testing.T) {
+	data := []byte(`{"a": [[1, 2], [3, [4]]], "b": ["xyz", {"c": [5, 6]}]}`)
+	for _, opts := range []ParseOptions{
+		{MaxDepth: 3}, {MaxItems: 10}, {MaxStringLength: 2}, {MaxInputBytes: 20},
+		{MaxDepth: 4, MaxItems: 15, MaxStringLength: 3},
+	} {
+		_, _, want := ParseWithOptions(data, opts)
+		_, got := unmarshalParallel(data, 1, opts)
+		var wantErr, gotErr *ParseError
+		errors.As(want, &wantErr)
+		errors.As(got, &gotErr)
+		if (wantErr == nil) != (gotErr == nil) || wantErr != nil && !errors.Is(got, wantErr.Err) {
+			t.Errorf("%+v: expected %v, got %v", opts, want, got)
+		}
+	}
+}
+
+func TestUnmarshalParallelWithOptions(t *testing.T) {
+	data := largeDocument(2000)
+	if _, err := UnmarshalParallelWithOptions(data, ParseOptions{MaxItems: 1000}); !errors.Is(err, ErrMaxItems) {
+		t.Errorf("expected ErrMaxItems, got %v", err)
+	}
+	if _, err := UnmarshalParallelWithOptions(data, ParseOptions{MaxDepth: 2}); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("expected ErrMaxDepth, got %v", err)
+	}
+	if _, err := UnmarshalParallelWithOptions(data, ParseOptions{MaxInputBytes: len(data) - 1}); !errors.Is(err, ErrMaxInputBytes) {
+		t.Errorf("expected ErrMaxInputBytes, got %v", err)
+	}
+	item, err := UnmarshalParallelWithOptions(data, ParseOptions{MaxDepth: 5, MaxInputBytes: len(data), Arena: new(Arena)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := Unmarshal(data); !Compare(item, want, true) {
+		t.Error("result differs from Unmarshal")
+	}
+}
@@ -0,0 +1,225 @@
+package cjsongo
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// minEncodeGrain is the smallest subtree, in nodes, worth encoding on
+// another goroutine.
+const minEncodeGrain = 4 << 10
+
+// MarshalParallel returns the same bytes as Marshal, but encodes the
+// members of large arrays and objects on several goroutines and joins the
+// pieces in order. Containers are split when v is an *Item, a
+// map[string]interface{} or a []interface{}, at any depth; other values are
+// encoded whole.
+func MarshalParallel(v interface{}) ([]byte, error) {
+	return marshalParallel(v, "", "", false, 0)
+}
+
+// MarshalIndentParallel is the parallel counterpart of json.MarshalIndent
+// and returns the same bytes.
+func MarshalIndentParallel(v interface{}, prefix, indent string) ([]byte, error) {
+	return marshalParallel(v, prefix, indent, true, 0)
+}
+
+// marshalParallel encodes v, splitting subtrees of at least grain nodes.
+// A zero grain is derived from the size of v and the number of CPUs.
+func marshalParallel(v interface{}, prefix, indent string, indented bool, grain int) ([]byte, error) {
+	e := &parallelEncoder{prefix: prefix, indent: indent, indented: indented, grain: grain}
+	w := nodeCount(v)
+	if grain == 0 {
+		procs := runtime.GOMAXPROCS(0)
+		e.grain = max(w/(4*procs), minEncodeGrain)
+		if procs == 1 || w < 2*e.grain {
+			return e.marshal(v, 0)
+		}
+	}
+	e.sem = make(chan struct{}, runtime.GOMAXPROCS(0))
+	return e.encode(v, 0, w)
+}
+
+type parallelEncoder struct {
+	prefix, indent string
+	indented       bool
+	grain          int
+	sem            chan struct{} // Limits extra goroutines to the number of CPUs.
+}
+
+// member is one element or member of a container being encoded.
+type member struct {
+	key   []byte // Encoded object key; nil for array elements.
+	value interface{}
+	nodes int
+}
+
+// members lists the children of v in output order, or reports false if v
+// is not a container that can be split.
+func members(v interface{}) (opening, closing byte, list []member, ok bool) {
+	switch x := v.(type) {
+	case *Item:
+		if x == nil || x.Type != Array && x.Type != Object {
+			return 0, 0, nil, false
+		}
+		opening, closing = '[', ']'
+		if x.Type == Object {
+			opening, closing = '{', '}'
+		}
+		list = make([]member, len(x.Children))
+		for i, child := range x.Children {
+			list[i].value = child
+			if x.Type == Object {
+				// Match the escaping json.Marshal applies to MarshalJSON output.
+				var key bytes.Buffer
+				json.HTMLEscape(&key, appendString(nil, child.Key))
+				list[i].key = key.Bytes()
+			}
+		}
+	case map[string]interface{}:
+		if x == nil {
+			return 0, 0, nil, false
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		list = make([]member, len(keys))
+		for i, k := range keys {
+			list[i].key, _ = json.Marshal(k)
+			list[i].value = x[k]
+		}
+		opening, closing = '{', '}'
+	case []interface{}:
+		if x == nil {
+			return 0, 0, nil, false
+		}
+		list = make([]member, len(x))
+		for i, elem := range x {
+			list[i].value = elem
+		}
+		opening, closing = '[', ']'
+	default:
+		return 0, 0, nil, false
+	}
+	return opening, closing, list, true
+}
+
+// nodeCount estimates the encoding work for v as its number of nodes.
+func nodeCount(v interface{}) int {
+	n := 1
+	switch x := v.(type) {
+	case *Item:
+		if x != nil {
+			for _, child := range x.Children {
+				n += nodeCount(child)
+			}
+		}
+	case map[string]interface{}:
+		for _, child := range x {
+			n += nodeCount(child)
+		}
+	case []interface{}:
+		for _, child := range x {
+			n += nodeCount(child)
+		}
+	}
+	return n
+}
+
+// marshal encodes v sequentially as if it were nested depth levels deep.
+func (e *parallelEncoder) marshal(v interface{}, depth int) ([]byte, error) {
+	if !e.indented {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, e.prefix+strings.Repeat(e.indent, depth), e.indent)
+}
+
+// encode encodes v, which has nodes nodes, at the given depth.
+func (e *parallelEncoder) encode(v interface{}, depth, nodes int) ([]byte, error) {
+	if nodes < e.grain {
+		return e.marshal(v, depth)
+	}
+	opening, closing, list, ok := members(v)
+	if !ok || len(list) == 0 {
+		return e.marshal(v, depth)
+	}
+	for i := range list {
+		list[i].nodes = nodeCount(list[i].value)
+	}
+
+	parts := make([][]byte, len(list))
+	var groups [][2]int
+	first, size := 0, 0
+	for i, m := range list {
+		size += m.nodes
+		if size >= e.grain || i == len(list)-1 {
+			groups = append(groups, [2]int{first, i + 1})
+			first, size = i+1, 0
+		}
+	}
+	errs := make([]error, len(groups))
+	var wg sync.WaitGroup
+	for g, group := range groups {
+		run := func() {
+			for i := group[0]; i < group[1]; i++ {
+				var err error
+				parts[i], err = e.encode(list[i].value, depth+1, list[i].nodes)
+				if err != nil {
+					errs[g] = err
+					return
+				}
+			}
+		}
+		select {
+		case e.sem <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() { <-e.sem; wg.Done() }()
+				run()
+			}()
+		default:
+			run() // All CPUs busy: encode inline rather than queueing.
+		}
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	size = 2
+	for i, part := range parts {
+		size += len(part) + len(list[i].key) + 2
+	}
+	var inner, outer []byte
+	if e.indented {
+		inner = []byte("\n" + e.prefix + strings.Repeat(e.indent, depth+1))
+		outer = []byte("\n" + e.prefix + strings.Repeat(e.indent, depth))
+		size += len(list)*(len(inner)+1) + len(outer)
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, opening)
+	for i, part := range parts {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, inner...)
+		if list[i].key != nil {
+			buf = append(buf, list[i].key...)
+			buf = append(buf, ':')
+			if e.indented {
+				buf = append(buf, ' ')
+			}
+		}
+		buf = append(buf, part...)
+	}
+	buf = append(buf, outer...)
+	return append(buf, closing), nil
+}
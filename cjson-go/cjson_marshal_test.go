@@ -0,0 +1,101 @@
+package cjsongo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// mixedDocument builds nested maps and slices like those decoded by
+// encoding/json, with strings that need escaping.
+func mixedDocument(n int) map[string]interface{} {
+	rows := make([]interface{}, n)
+	for i := range rows {
+		rows[i] = map[string]interface{}{
+			"id":    float64(i),
+			"name":  fmt.Sprintf("<row & %d> \"", i),
+			"tags":  []interface{}{"a", nil, true, 1.5},
+			"empty": map[string]interface{}{},
+			"none":  []interface{}{},
+		}
+	}
+	return map[string]interface{}{
+		"rows":   rows,
+		"meta":   map[string]interface{}{"count": float64(n), "nested": map[string]interface{}{"rows": rows[:n/2]}},
+		"scalar": "x",
+		"nil":    nil,
+	}
+}
+
+func TestMarshalParallelMatchesEncodingJSON(t *testing.T) {
+	doc := mixedDocument(300)
+	itemDoc, err := Unmarshal(largeDocument(300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AddStringToObject(itemDoc, "html <&>", "a<b>& \x01")
+	for _, v := range []interface{}{doc, itemDoc, []interface{}{doc, itemDoc, 1, "s"}, itemDoc.Children[1]} {
+		wantCompact, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantIndent, err := json.MarshalIndent(v, "> ", "\t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, grain := range []int{1, 7, 100, 1 << 20} {
+			got, err := marshalParallel(v, "", "", false, grain)
+			if err != nil || string(got) != string(wantCompact) {
+				t.Fatalf("grain %d: compact output differs (%v)", grain, err)
+			}
+			got, err = marshalParallel(v, "> ", "\t", true, grain)
+			if err != nil || string(got) != string(wantIndent) {
+				t.Fatalf("grain %d: indented output differs (%v)\n%.300s\n%.300s", grain, err, got, wantIndent)
+			}
+		}
+		if got, err := MarshalParallel(v); err != nil || string(got) != string(wantCompact) {
+			t.Errorf("MarshalParallel differs from json.Marshal (%v)", err)
+		}
+		if got, err := MarshalIndentParallel(v, "> ", "\t"); err != nil || string(got) != string(wantIndent) {
+			t.Errorf("MarshalIndentParallel differs from json.MarshalIndent (%v)", err)
+		}
+	}
+}
+
+func TestMarshalParallelErrors(t *testing.T) {
+	doc := mixedDocument(50)
+	doc["rows"].([]interface{})[30].(map[string]interface{})["bad"] = math.Inf(1)
+	_, err := marshalParallel(doc, "", "", false, 3)
+	var uve *json.UnsupportedValueError
+	if !errors.As(err, &uve) {
+		t.Errorf("expected *json.UnsupportedValueError, got %v", err)
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	item, err := Unmarshal(largeDocument(40000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(item); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalParallel(b *testing.B) {
+	item, err := Unmarshal(largeDocument(40000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := MarshalParallel(item); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package cjsongo
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by the number getters, wrapped with the offending literal.
+var (
+	ErrNotNumber   = errors.New("item is not a number")
+	ErrNotInteger  = errors.New("number is not an integer")
+	ErrNumberRange = errors.New("number out of range")
+)
+
+// maxBigIntDigits bounds the size of integers produced by BigInt, so that a
+// short literal such as 1e999999999 cannot demand gigabytes of memory.
+const maxBigIntDigits = 10000
+
+// setLiteral records text as the literal of a number item whose ValueDouble
+// has been set from it.
+func (item *Item) setLiteral(text string) {
+	item.numText = text
+	item.numDouble = item.ValueDouble
+}
+
+// literal returns the number's original literal, or "" if there is none or
+// ValueDouble has been changed since it was parsed.
+func (item *Item) literal() string {
+	if item.numText == "" || math.Float64bits(item.ValueDouble) != math.Float64bits(item.numDouble) {
+		return ""
+	}
+	return item.numText
+}
+
+// NumberText returns the literal of a number item exactly as it appeared in
+// the input. Items created or changed in code yield the shortest literal for
+// ValueDouble. It returns "" for other items.
+func (item *Item) NumberText() string {
+	if !IsNumber(item) {
+		return ""
+	}
+	if text := item.literal(); text != "" {
+		return text
+	}
+	return strconv.FormatFloat(item.ValueDouble, 'g', -1, 64)
+}
+
+func (item *Item) numberText() (string, error) {
+	if !IsNumber(item) {
+		return "", ErrNotNumber
+	}
+	return item.NumberText(), nil
+}
+
+// Int64 returns the value of a number item as an int64. The literal must
+// denote an integer, though it may be written as 1.0 or 1e3.
+func (item *Item) Int64() (int64, error) {
+	text, err := item.numberText()
+	if err != nil {
+		return 0, err
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, nil
+	}
+	digits, err := integerDigits(text, 20)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s does not fit in int64", ErrNumberRange, text)
+	}
+	return n, nil
+}
+
+// Uint64 returns the value of a number item as a uint64. The literal must
+// denote a non-negative integer.
+func (item *Item) Uint64() (uint64, error) {
+	text, err := item.numberText()
+	if err != nil {
+		return 0, err
+	}
+	if n, err := strconv.ParseUint(text, 10, 64); err == nil {
+		return n, nil
+	}
+	digits, err := integerDigits(text, 20)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s does not fit in uint64", ErrNumberRange, text)
+	}
+	return n, nil
+}
+
+// Float64 returns the value of a number item as the nearest float64. Unlike
+// ValueDouble, which saturates to an infinity, a literal beyond the float64
+// range is an error.
+func (item *Item) Float64() (float64, error) {
+	text, err := item.numberText()
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s does not fit in float64", ErrNumberRange, text)
+	}
+	return f, nil
+}
+
+// BigInt returns the exact value of a number item that denotes an integer.
+// Integers of more than 10000 digits are rejected with ErrNumberRange.
+func (item *Item) BigInt() (*big.Int, error) {
+	text, err := item.numberText()
+	if err != nil {
+		return nil, err
+	}
+	digits, err := integerDigits(text, maxBigIntDigits)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := new(big.Int).SetString(digits, 10)
+	return n, nil
+}
+
+// BigFloat returns the value of a number item as a big.Float whose
+// precision is enough to hold every significant digit of the literal.
+func (item *Item) BigFloat() (*big.Float, error) {
+	text, err := item.numberText()
+	if err != nil {
+		return nil, err
+	}
+	mantissa, _, _ := splitNumber(text)
+	prec := max(uint(float64(len(mantissa))*math.Log2(10))+1, 64)
+	f, _, err := big.ParseFloat(text, 10, prec, big.ToNearestEven)
+	if err != nil || f.IsInf() {
+		return nil, fmt.Errorf("%w: %s exceeds the big.Float exponent range", ErrNumberRange, text)
+	}
+	return f, nil
+}
+
+// splitNumber breaks a JSON number literal into its significant digits,
+// without leading zeros, and the power of ten they are scaled by.
+func splitNumber(text string) (mantissa string, exp int64, negative bool) {
+	if strings.HasPrefix(text, "-") {
+		negative, text = true, text[1:]
+	}
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		// Exponents too large for int64 saturate; any non-zero mantissa
+		// then fails the range checks.
+		e, err := strconv.ParseInt(text[i+1:], 10, 64)
+		if err != nil {
+			e = math.MaxInt64 / 2
+			if text[i+1] == '-' {
+				e = math.MinInt64 / 2
+			}
+		}
+		exp, text = e, text[:i]
+	}
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		exp -= int64(len(text) - i - 1)
+		text = text[:i] + text[i+1:]
+	}
+	return strings.TrimLeft(text, "0"), exp, negative
+}
+
+// integerDigits returns the decimal digits of the integer a literal denotes,
+// with a leading '-' if it is negative, or an error if it is fractional or
+// has more than maxDigits digits.
+func integerDigits(text string, maxDigits int) (string, error) {
+	mantissa, exp, negative := splitNumber(text)
+	if mantissa == "" {
+		return "0", nil
+	}
+	if exp < 0 {
+		trimmed := strings.TrimRight(mantissa, "0")
+		if int64(len(mantissa)-len(trimmed)) < -exp {
+			return "", fmt.Errorf("%w: %s", ErrNotInteger, text)
+		}
+		mantissa = mantissa[:int64(len(mantissa))+exp]
+		exp = 0
+	}
+	if int64(len(mantissa))+exp > int64(maxDigits) {
+		return "", fmt.Errorf("%w: %s", ErrNumberRange, text)
+	}
+	digits := mantissa + strings.Repeat("0", int(exp))
+	if negative {
+		digits = "-" + digits
+	}
+	return digits, nil
+}
+
+// compareNumbers orders two number items by the exact values of their
+// literals, so integers beyond float64 precision compare correctly. Items
+// holding NaN or an infinity without a literal compare by ValueDouble.
+func compareNumbers(a, b *Item) int {
+	if !hasDecimal(a) || !hasDecimal(b) {
+		return cmp.Compare(a.ValueDouble, b.ValueDouble)
+	}
+	ma, ea, na := splitNumber(a.NumberText())
+	mb, eb, nb := splitNumber(b.NumberText())
+	sa, sb := numberSign(ma, na), numberSign(mb, nb)
+	if sa != sb || sa == 0 {
+		return cmp.Compare(sa, sb)
+	}
+	ma, ea = trimTrailingZeros(ma, ea)
+	mb, eb = trimTrailingZeros(mb, eb)
+	// Compare the position of the leading digit, then the digits.
+	c := cmp.Compare(int64(len(ma))+ea, int64(len(mb))+eb)
+	if c == 0 {
+		n := min(len(ma), len(mb))
+		c = cmp.Or(strings.Compare(ma[:n], mb[:n]), cmp.Compare(len(ma), len(mb)))
+	}
+	return c * sa
+}
+
+func hasDecimal(item *Item) bool {
+	return item.literal() != "" || !math.IsInf(item.ValueDouble, 0) && !math.IsNaN(item.ValueDouble)
+}
+
+func numberSign(mantissa string, negative bool) int {
+	switch {
+	case strings.Trim(mantissa, "0") == "":
+		return 0
+	case negative:
+		return -1
+	}
+	return 1
+}
+
+func trimTrailingZeros(mantissa string, exp int64) (string, int64) {
+	trimmed := strings.TrimRight(mantissa, "0")
+	return trimmed, exp + int64(len(mantissa)-len(trimmed))
+}
@@ -0,0 +1,125 @@
+package cjsongo
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNumberLiteralsPrintExactly(t *testing.T) {
+	in := `[12345678901234567890,-0,1.50,1E+2,0.1,-9223372036854775809,1e400,2.5e-400,123456789012345678901234567890.000001]`
+	item, err := Parse([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := PrintUnformatted(item); got != in {
+		t.Errorf("expected %s, got %s", in, got)
+	}
+	if got := PrintUnformatted(Duplicate(item, true)); got != in {
+		t.Errorf("duplicate lost literals: %s", got)
+	}
+
+	n := GetArrayItem(item, 2)
+	n.ValueDouble = 2
+	if got := PrintUnformatted(n); got != "2" {
+		t.Errorf("expected a changed value to be printed from ValueDouble, got %s", got)
+	}
+	if got := n.NumberText(); got != "2" {
+		t.Errorf("expected NumberText to follow ValueDouble, got %s", got)
+	}
+	if CreateNumber(0.5).NumberText() != "0.5" || CreateString("1").NumberText() != "" {
+		t.Error("unexpected NumberText for items without a literal")
+	}
+}
+
+func TestNumberGetters(t *testing.T) {
+	tests := []struct {
+		in     string
+		i64    int64
+		i64Err error
+		u64    uint64
+		u64Err error
+		f64    float64
+		f64Err error
+		bigInt string
+		bigErr error
+	}{
+		{"42", 42, nil, 42, nil, 42, nil, "42", nil},
+		{"-7", -7, nil, 0, ErrNumberRange, -7, nil, "-7", nil},
+		{"-0", 0, nil, 0, nil, math.Copysign(0, -1), nil, "0", nil},
+		{"1e3", 1000, nil, 1000, nil, 1000, nil, "1000", nil},
+		{"2.50e1", 25, nil, 25, nil, 25, nil, "25", nil},
+		{"1.5", 0, ErrNotInteger, 0, ErrNotInteger, 1.5, nil, "", ErrNotInteger},
+		{"9223372036854775807", math.MaxInt64, nil, math.MaxInt64, nil, 9223372036854775807, nil, "9223372036854775807", nil},
+		{"9223372036854775808", 0, ErrNumberRange, 1 << 63, nil, 9223372036854775808, nil, "9223372036854775808", nil},
+		{"18446744073709551616", 0, ErrNumberRange, 0, ErrNumberRange, 18446744073709551616, nil, "18446744073709551616", nil},
+		{"1e400", 0, ErrNumberRange, 0, ErrNumberRange, 0, ErrNumberRange, "1" + strings.Repeat("0", 400), nil},
+		{"1e99999", 0, ErrNumberRange, 0, ErrNumberRange, 0, ErrNumberRange, "", ErrNumberRange},
+		{"5e-1", 0, ErrNotInteger, 0, ErrNotInteger, 0.5, nil, "", ErrNotInteger},
+	}
+	for _, tt := range tests {
+		item, err := Parse([]byte(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, err := item.Int64(); !errors.Is(err, tt.i64Err) || err == nil && n != tt.i64 {
+			t.Errorf("%s: Int64 = %d, %v", tt.in, n, err)
+		}
+		if n, err := item.Uint64(); !errors.Is(err, tt.u64Err) || err == nil && n != tt.u64 {
+			t.Errorf("%s: Uint64 = %d, %v", tt.in, n, err)
+		}
+		if f, err := item.Float64(); !errors.Is(err, tt.f64Err) || err == nil && math.Float64bits(f) != math.Float64bits(tt.f64) {
+			t.Errorf("%s: Float64 = %v, %v", tt.in, f, err)
+		}
+		if b, err := item.BigInt(); !errors.Is(err, tt.bigErr) || err == nil && b.String() != tt.bigInt {
+			t.Errorf("%s: BigInt = %v, %v", tt.in, b, err)
+		}
+	}
+
+	item, _ := Parse([]byte(`123456789012345678901234567890.000001`))
+	f, err := item.BigFloat()
+	if err != nil || f.Text('f', 6) != "123456789012345678901234567890.000001" {
+		t.Errorf("BigFloat = %v, %v", f, err)
+	}
+	if _, err := CreateString("1").Int64(); !errors.Is(err, ErrNotNumber) {
+		t.Errorf("expected ErrNotNumber, got %v", err)
+	}
+	if n, err := CreateNumber(1e21).BigInt(); err != nil || n.String() != "1"+strings.Repeat("0", 21) {
+		t.Errorf("BigInt of a created number = %v, %v", n, err)
+	}
+}
+
+func TestCompareNumbers(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"9007199254740993", "9007199254740992", 1},
+		{"1", "1.000", 0},
+		{"100", "1e2", 0},
+		{"-0", "0.0e5", 0},
+		{"0.1", "0.10000000000000000001", -1},
+		{"-2", "-10", 1},
+		{"-1", "0", -1},
+		{"12.5", "1.25e1", 0},
+		{"1e400", "1e399", 1},
+		{"-1e400", "1", -1},
+		{"123", "12.3e1", 0},
+		{"0.0012", "12e-4", 0},
+		{"99", "100", -1},
+	}
+	for _, tt := range tests {
+		a, _ := Parse([]byte(tt.a))
+		b, _ := Parse([]byte(tt.b))
+		if got := compareNumbers(a, b); got != tt.want {
+			t.Errorf("%s vs %s: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+		if got := compareNumbers(b, a); got != -tt.want {
+			t.Errorf("%s vs %s: expected %d, got %d", tt.b, tt.a, -tt.want, got)
+		}
+	}
+	if compareNumbers(CreateNumber(math.Inf(1)), CreateNumber(1e308)) != 1 {
+		t.Error("expected +Inf without a literal to compare by value")
+	}
+}
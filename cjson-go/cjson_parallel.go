@@ -0,0 +1,243 @@
+package cjsongo
+
+import (
+	"runtime"
+	"sync"
+)
+
+// minParallelGrain is the smallest subtree, in bytes, worth handing to
+// another goroutine.
+const minParallelGrain = 32 << 10
+
+// childSpan locates one array element or object member value in the input.
+type childSpan struct {
+	key        string
+	start, end int
+}
+
+// parallelParser parses a document by scanning containers for their child
+// boundaries and parsing large children concurrently. Children that are
+// still large are split again, so one huge nested member is parallelized
+// too. Every value is parsed by the ordinary parser, so the result and the
+// accepted inputs are exactly those of Parse.
+type parallelParser struct {
+	data   []byte
+	grain  int
+	sem    chan struct{} // Limits extra goroutines to the number of CPUs.
+	limits               // Shared by all goroutines; the item counter is atomic.
+}
+
+func unmarshalParallel(data []byte, grain int, opts ParseOptions) (*Item, error) {
+	workers := runtime.GOMAXPROCS(0)
+	pp := &parallelParser{data: data, grain: grain, sem: make(chan struct{}, workers), limits: newLimits(opts)}
+	p := pp.parser(0, 0)
+	if err := p.checkInputBytes(); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	start := p.pos
+	end, err := p.skipValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(data) {
+		return nil, p.errorf("unexpected data after top-level value")
+	}
+	return pp.parse(start, end, 0)
+}
+
+// parser returns a sequential parser over the whole input positioned at pos
+// inside depth open containers.
+func (pp *parallelParser) parser(pos, depth int) *parser {
+	return &parser{data: pp.data, pos: pos, depth: depth, limits: pp.limits}
+}
+
+// parse parses the value occupying data[start:end], which is nested inside
+// depth containers.
+func (pp *parallelParser) parse(start, end, depth int) (*Item, error) {
+	c := pp.data[start]
+	if end-start < pp.grain || c != '{' && c != '[' {
+		return pp.parseSpan(start, end, depth)
+	}
+	p := pp.parser(start, depth)
+	if err := p.countItem(); err != nil {
+		return nil, err
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	typ, spans, err := pp.scanChildren(p)
+	if err != nil {
+		return nil, err
+	}
+	item := &Item{Type: typ, Children: make([]*Item, len(spans))}
+	groups := pp.group(spans)
+	errs := make([]error, len(groups))
+	var wg sync.WaitGroup
+	for g, group := range groups {
+		run := func() {
+			for i := group.first; i < group.last; i++ {
+				child, err := pp.parse(spans[i].start, spans[i].end, depth+1)
+				if err != nil {
+					errs[g] = err
+					return
+				}
+				child.Key = spans[i].key
+				item.Children[i] = child
+			}
+		}
+		select {
+		case pp.sem <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() { <-pp.sem; wg.Done() }()
+				run()
+			}()
+		default:
+			run() // All CPUs busy: parse inline rather than queueing.
+		}
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+// parseSpan parses data[start:end] sequentially and checks that the value
+// fills the span exactly.
+func (pp *parallelParser) parseSpan(start, end, depth int) (*Item, error) {
+	p := pp.parser(start, depth)
+	p.data = p.data[:end]
+	item, err := p.parseValue()
+	if err == nil && p.pos != end {
+		err = p.errorf("unexpected data after value")
+	}
+	if pe, ok := err.(*ParseError); ok {
+		// Rebuild the error against the whole input so the snippet is not
+		// cut short at the span end.
+		full := pp.parser(pe.Offset, depth).newError(pe.Expected, pe.Msg)
+		full.Err = pe.Err
+		return nil, full
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+type spanGroup struct{ first, last int }
+
+// group cuts spans into consecutive runs of roughly grain bytes each.
+func (pp *parallelParser) group(spans []childSpan) []spanGroup {
+	var groups []spanGroup
+	first, size := 0, 0
+	for i, s := range spans {
+		size += s.end - s.start
+		if size >= pp.grain || i == len(spans)-1 {
+			groups = append(groups, spanGroup{first, i + 1})
+			first, size = i+1, 0
+		}
+	}
+	return groups
+}
+
+// scanChildren finds the child values of the container at the read position
+// of p. Object keys are decoded and separators checked; the values
+// themselves are only skipped and are validated when they are parsed.
+func (pp *parallelParser) scanChildren(p *parser) (Type, []childSpan, error) {
+	typ, closing := Array, byte(']')
+	if pp.data[p.pos] == '{' {
+		typ, closing = Object, '}'
+	}
+	p.pos++
+	var spans []childSpan
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == closing {
+		return typ, spans, nil
+	}
+	for {
+		var key string
+		p.skipSpace()
+		if typ == Object {
+			if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+				return 0, nil, p.expected("object key string")
+			}
+			var err error
+			if key, err = p.parseString(); err != nil {
+				return 0, nil, err
+			}
+			p.skipSpace()
+			if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+				return 0, nil, p.expected("':' after object key")
+			}
+			p.pos++
+			p.skipSpace()
+		}
+		vstart := p.pos
+		vend, err := p.skipValue()
+		if err != nil {
+			return 0, nil, err
+		}
+		spans = append(spans, childSpan{key: key, start: vstart, end: vend})
+		p.skipSpace()
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == closing {
+			return typ, spans, nil
+		}
+		return 0, nil, p.expected("',' or '" + string(closing) + "'")
+	}
+}
+
+// skipValue moves past the value at the read position without building it
+// and returns its end offset. Brackets are balanced and strings skipped, but
+// the contents are not otherwise checked.
+func (p *parser) skipValue() (int, error) {
+	if p.pos >= len(p.data) {
+		return 0, p.expected("a value")
+	}
+	depth := 0
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			for p.pos < len(p.data) && p.data[p.pos] != '"' {
+				if p.data[p.pos] == '\\' {
+					p.pos++
+				}
+				p.pos++
+			}
+			if p.pos >= len(p.data) {
+				return 0, p.expected(`closing '"'`)
+			}
+			p.pos++
+		case c == '{' || c == '[':
+			depth++
+			p.pos++
+		case c == '}' || c == ']':
+			if depth == 0 {
+				return p.pos, nil
+			}
+			depth--
+			p.pos++
+		case depth == 0 && (c == ',' || c == ':' || c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			return p.pos, nil
+		default:
+			p.pos++
+		}
+		if depth == 0 && (c == '"' || c == '}' || c == ']') {
+			return p.pos, nil
+		}
+	}
+	if depth > 0 {
+		return 0, p.expected("closing bracket")
+	}
+	return p.pos, nil
+}
@@ -0,0 +1,94 @@
+package cjsongo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// largeDocument builds an object whose members include a big array of
+// records and a nested object holding another big array.
+func largeDocument(n int) []byte {
+	var b strings.Builder
+	b.WriteString(`{"meta": {"count": ` + fmt.Sprint(n) + `}, "items": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "name": "item\"%d", "tags": ["a", "b"], "score": %d.5, "ok": true}`, i, i, i)
+	}
+	b.WriteString(`], "nested": {"inner": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `[%d, null, {"k": "v"}]`, i)
+	}
+	b.WriteString(`]}, "dup": 1, "dup": 2}`)
+	return []byte(b.String())
+}
+
+func TestUnmarshalParallelMatchesUnmarshal(t *testing.T) {
+	data := largeDocument(2000)
+	want, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, grain := range []int{64, 1024, 1 << 20} {
+		got, err := unmarshalParallel(data, grain, ParseOptions{})
+		if err != nil {
+			t.Fatalf("grain %d: %v", grain, err)
+		}
+		if PrintUnformatted(got) != PrintUnformatted(want) {
+			t.Errorf("grain %d: parallel result differs from Unmarshal", grain)
+		}
+	}
+	got, err := UnmarshalParallel(data)
+	if err != nil || PrintUnformatted(got) != PrintUnformatted(want) {
+		t.Errorf("UnmarshalParallel differs from Unmarshal (%v)", err)
+	}
+}
+
+func TestUnmarshalParallelErrors(t *testing.T) {
+	data := largeDocument(200)
+	for _, bad := range []string{
+		strings.Replace(string(data), `"k": "v"`, `"k" "v"`, 1),
+		strings.Replace(string(data), `null`, `nul`, 1),
+		strings.Replace(string(data), `[0, null`, `[0 null`, 1),
+		strings.Replace(string(data), `"ok": true}]`, `"ok": true}}`, 1),
+		string(data) + "x",
+		string(data[:len(data)-1]),
+	} {
+		if _, err := Unmarshal([]byte(bad)); err == nil {
+			t.Fatal("test input should be invalid")
+		}
+		if _, err := unmarshalParallel([]byte(bad), 64, ParseOptions{}); err == nil {
+			t.Errorf("expected error for invalid input")
+		}
+	}
+}
+
+// The large-input benchmarks live here rather than in cjson_bench.go so that
+// go test -bench picks them up. Compare with -cpu=1,2,4,8.
+
+func BenchmarkUnmarshalLarge(b *testing.B) {
+	data := largeDocument(40000) // About 6 MB.
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Unmarshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalParallelLarge(b *testing.B) {
+	data := largeDocument(40000)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalParallel(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
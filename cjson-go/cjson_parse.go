@@ -0,0 +1,430 @@
+package cjsongo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Parse parses one JSON value into an Item tree with a native recursive
+// descent parser. Unlike cJSON_Parse, data after the value other than
+// whitespace is an error. Nesting deeper than NestingLimit is rejected.
+func Parse(data []byte) (*Item, error) {
+	p := newParser(data, ParseOptions{})
+	return p.parseRoot(true)
+}
+
+// ParseOptions configures ParseWithOptions.
+type ParseOptions struct {
+	// RequireEnd makes anything other than whitespace after the value an
+	// error, like cJSON's require_null_terminated. Without it parsing stops
+	// at the end of the first value and the rest of data is left alone.
+	RequireEnd bool
+
+	// MaxDepth limits how deeply arrays and objects may nest. Zero means
+	// NestingLimit; a negative value disables the check.
+	MaxDepth int
+
+	// MaxInputBytes limits the size of the input. Zero means no limit.
+	MaxInputBytes int
+
+	// MaxItems limits the number of items in the tree. Zero means no limit.
+	MaxItems int
+
+	// MaxStringLength limits the decoded length in bytes of each string
+	// value and object key. Zero means no limit.
+	MaxStringLength int
+
+	// Arena, if set, supplies the memory for the parsed tree.
+	Arena *Arena
+}
+
+// ParseWithOptions parses the first JSON value in data, like
+// cJSON_ParseWithOpts, and returns it with the number of bytes consumed:
+// the offset just past the value, or len(data) when RequireEnd is set. On a
+// syntax error n is the offset where parsing stopped. If data holds nothing
+// but whitespace the error is io.EOF, so consecutive values can be read with
+//
+//	for {
+//		item, n, err := cjsongo.ParseWithOptions(data, cjsongo.ParseOptions{})
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//		data = data[n:]
+//	}
+//
+// Exceeding one of the limits in opts fails with a *ParseError wrapping
+// ErrMaxDepth, ErrMaxInputBytes, ErrMaxItems or ErrMaxStringLength.
+func ParseWithOptions(data []byte, opts ParseOptions) (item *Item, n int, err error) {
+	p := newParser(data, opts)
+	if err := p.checkInputBytes(); err != nil {
+		return nil, p.pos, err
+	}
+	p.skipSpace()
+	if p.pos == len(p.data) {
+		return nil, p.pos, io.EOF
+	}
+	item, err = p.parseRoot(opts.RequireEnd)
+	return item, p.pos, err
+}
+
+// parseRoot parses a top-level value and, if requireEnd is set, checks that
+// only whitespace follows it.
+func (p *parser) parseRoot(requireEnd bool) (*Item, error) {
+	item, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if requireEnd {
+		p.skipSpace()
+		if p.pos != len(p.data) {
+			return nil, p.errorf("unexpected data after top-level value")
+		}
+	}
+	return item, nil
+}
+
+// parser holds the state of one parse, like cJSON's parse_buffer.
+type parser struct {
+	data  []byte
+	pos   int
+	stack []*Item // Children of the containers being parsed.
+	depth int     // Containers currently open.
+	arena *Arena  // Nil to allocate each item separately.
+	limits
+}
+
+func (p *parser) newItem(typ Type) *Item {
+	if p.arena != nil {
+		item := p.arena.newItem()
+		item.Type = typ
+		return item
+	}
+	return &Item{Type: typ}
+}
+
+// children copies the children of a finished container out of the scratch
+// stack.
+func (p *parser) children(stack []*Item) []*Item {
+	if p.arena != nil {
+		return p.arena.children(stack)
+	}
+	return append([]*Item(nil), stack...)
+}
+
+func (p *parser) string(b []byte) string {
+	if p.arena != nil {
+		return p.arena.string(b)
+	}
+	return string(b)
+}
+
+// ParseError describes where and why parsing failed, like
+// cJSON_GetErrorPtr with line and column information added. Every parse
+// entry point returns a *ParseError for malformed input; use errors.As to
+// retrieve it from wrapped errors.
+type ParseError struct {
+	Offset   int    // Byte offset where parsing stopped.
+	Line     int    // 1-based line of Offset.
+	Column   int    // 1-based byte column of Offset.
+	Snippet  string // Input surrounding Offset.
+	Expected string // What the parser was looking for, if anything.
+	Msg      string // Description of the problem.
+	Err      error  // Limit that was exceeded, if any.
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at line %d, column %d (offset %d): %s", e.Line, e.Column, e.Offset, e.Msg)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// snippetRadius is how many bytes of input ParseError.Snippet holds on
+// either side of the error offset.
+const snippetRadius = 16
+
+func (p *parser) errorf(format string, args ...any) error {
+	return p.newError("", fmt.Sprintf(format, args...))
+}
+
+func (p *parser) newError(expected, msg string) *ParseError {
+	off := min(p.pos, len(p.data))
+	line := 1 + bytes.Count(p.data[:off], []byte{'\n'})
+	lineStart := bytes.LastIndexByte(p.data[:off], '\n') + 1
+	lo, hi := max(off-snippetRadius, 0), min(off+snippetRadius, len(p.data))
+	for lo > 0 && !utf8.RuneStart(p.data[lo]) {
+		lo--
+	}
+	for hi < len(p.data) && !utf8.RuneStart(p.data[hi]) {
+		hi++
+	}
+	return &ParseError{
+		Offset:   off,
+		Line:     line,
+		Column:   off - lineStart + 1,
+		Snippet:  string(p.data[lo:hi]),
+		Expected: expected,
+		Msg:      msg,
+	}
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) parseValue() (*Item, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, p.expected("a value")
+	}
+	if err := p.countItem(); err != nil {
+		return nil, err
+	}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.parseContainer(Object, '}')
+	case c == '[':
+		return p.parseContainer(Array, ']')
+	case c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		item := p.newItem(String)
+		item.ValueString = s
+		return item, nil
+	case c == '-' || c >= '0' && c <= '9':
+		return p.parseNumber()
+	}
+	for _, lit := range [...]struct {
+		text string
+		typ  Type
+	}{{"true", True}, {"false", False}, {"null", Null}} {
+		if len(p.data)-p.pos >= len(lit.text) && string(p.data[p.pos:p.pos+len(lit.text)]) == lit.text {
+			p.pos += len(lit.text)
+			return p.newItem(lit.typ), nil
+		}
+	}
+	return nil, p.expected("a value")
+}
+
+// parseContainer parses an array or object starting at its opening bracket.
+func (p *parser) parseContainer(typ Type, closing byte) (*Item, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	p.pos++
+	item := p.newItem(typ)
+	mark := len(p.stack)
+	defer func() { p.stack = p.stack[:mark] }()
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == closing {
+		p.pos++
+		return item, nil
+	}
+	for {
+		var key string
+		if typ == Object {
+			p.skipSpace()
+			if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+				return nil, p.expected("object key string")
+			}
+			var err error
+			if key, err = p.parseString(); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+				return nil, p.expected("':' after object key")
+			}
+			p.pos++
+		}
+		child, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		child.Key = key
+		p.stack = append(p.stack, child)
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, p.expected(fmt.Sprintf("',' or '%c'", closing))
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+			continue
+		case closing:
+			p.pos++
+			item.Children = p.children(p.stack[mark:])
+			return item, nil
+		}
+		return nil, p.expected(fmt.Sprintf("',' or '%c'", closing))
+	}
+}
+
+func (p *parser) expected(what string) error {
+	if p.pos >= len(p.data) {
+		return p.newError(what, "unexpected end of input, expected "+what)
+	}
+	return p.newError(what, fmt.Sprintf("invalid character %q, expected %s", p.data[p.pos], what))
+}
+
+// parseString parses the string starting at the opening quote.
+func (p *parser) parseString() (string, error) {
+	start := p.pos + 1
+	i := start
+	for i < len(p.data) {
+		c := p.data[i]
+		if c == '"' {
+			if err := p.checkString(start, i-start); err != nil {
+				return "", err
+			}
+			p.pos = i + 1
+			return p.string(p.data[start:i]), nil
+		}
+		if c == '\\' || c < 0x20 {
+			break
+		}
+		i++
+	}
+	buf := append(make([]byte, 0, i-start+16), p.data[start:i]...)
+	for i < len(p.data) {
+		c := p.data[i]
+		switch {
+		case c == '"':
+			if err := p.checkString(start, len(buf)); err != nil {
+				return "", err
+			}
+			p.pos = i + 1
+			return p.string(buf), nil
+		case c < 0x20:
+			p.pos = i
+			return "", p.errorf("invalid control character %q in string", c)
+		case c != '\\':
+			buf = append(buf, c)
+			i++
+			continue
+		}
+		if err := p.checkString(start, len(buf)); err != nil {
+			return "", err
+		}
+		if i+1 >= len(p.data) {
+			break
+		}
+		switch e := p.data[i+1]; e {
+		case '"', '\\', '/':
+			buf = append(buf, e)
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'u':
+			r, ok := p.hex4(i + 2)
+			if !ok {
+				p.pos = i
+				return "", p.errorf("invalid \\u escape in string")
+			}
+			i += 6
+			if utf16.IsSurrogate(r) {
+				if r2, ok := p.hex4(i + 2); ok && i+1 < len(p.data) && p.data[i] == '\\' && p.data[i+1] == 'u' {
+					if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+						buf = utf8.AppendRune(buf, dec)
+						i += 6
+						continue
+					}
+				}
+				r = utf8.RuneError
+			}
+			buf = utf8.AppendRune(buf, r)
+			continue
+		default:
+			p.pos = i
+			return "", p.errorf("invalid escape %q in string", e)
+		}
+		i += 2
+	}
+	p.pos = len(p.data)
+	return "", p.expected(`closing '"'`)
+}
+
+// hex4 decodes the four hex digits at data[i].
+func (p *parser) hex4(i int) (rune, bool) {
+	if i+4 > len(p.data) {
+		return 0, false
+	}
+	var r rune
+	for _, c := range p.data[i : i+4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
+
+// parseNumber parses a number following the RFC 8259 grammar and keeps its
+// literal text.
+func (p *parser) parseNumber() (*Item, error) {
+	start := p.pos
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+			p.pos++
+			n++
+		}
+		return n
+	}
+	if p.data[p.pos] == '-' {
+		p.pos++
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '0' {
+		p.pos++
+	} else if digits() == 0 {
+		return nil, p.expected("digit")
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '.' {
+		p.pos++
+		if digits() == 0 {
+			return nil, p.expected("digit after decimal point")
+		}
+	}
+	if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return nil, p.expected("digit in exponent")
+		}
+	}
+	text := p.string(p.data[start:p.pos])
+	item := p.newItem(Number)
+	item.ValueDouble, _ = strconv.ParseFloat(text, 64) // Out of range values become ±Inf or 0, as with strtod.
+	item.setLiteral(text)
+	return item, nil
+}
@@ -0,0 +1,94 @@
+package cjsongo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference
+// tokens. The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must be empty or start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, tok := range tokens {
+		var ok bool
+		if tokens[i], ok = unescapeToken(tok); !ok {
+			return nil, fmt.Errorf("invalid JSON pointer %q: bad escape in %q", pointer, tok)
+		}
+	}
+	return tokens, nil
+}
+
+// unescapeToken decodes the ~0 and ~1 escapes in a reference token. It
+// reports false if a '~' is not followed by '0' or '1'.
+func unescapeToken(tok string) (string, bool) {
+	if !strings.Contains(tok, "~") {
+		return tok, true
+	}
+	if strings.Count(tok, "~") != strings.Count(tok, "~0")+strings.Count(tok, "~1") {
+		return "", false
+	}
+	return pointerUnescaper.Replace(tok), true
+}
+
+// arrayIndex parses a pointer token as an array index. "-", meaning the
+// position after the last element, yields size.
+func arrayIndex(tok string, size int) (int, bool) {
+	if tok == "-" {
+		return size, true
+	}
+	if tok == "" || len(tok) > 1 && tok[0] == '0' {
+		return 0, false
+	}
+	i, err := strconv.Atoi(tok)
+	return i, err == nil && i >= 0
+}
+
+// childIndex finds the child of item that tok refers to: an element index
+// for arrays, the first member with that exact name for objects.
+func childIndex(item *Item, tok string) (int, bool) {
+	switch item.Type {
+	case Array:
+		i, ok := arrayIndex(tok, len(item.Children))
+		return i, ok && i < len(item.Children)
+	case Object:
+		for i, child := range item.Children {
+			if child.Key == tok {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// GetPointer returns the item that an RFC 6901 JSON Pointer such as
+// "/items/0/name" refers to, like cJSONUtils_GetPointerCaseSensitive, or nil
+// if there is none.
+func GetPointer(item *Item, pointer string) *Item {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil
+	}
+	for _, tok := range tokens {
+		if item == nil {
+			return nil
+		}
+		i, ok := childIndex(item, tok)
+		if !ok {
+			return nil
+		}
+		item = item.Children[i]
+	}
+	return item
+}
@@ -0,0 +1,160 @@
+package cjsongo
+
+import (
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Print renders item as formatted JSON in cJSON's layout: object members on
+// their own lines indented with tabs, arrays on one line.
+func Print(item *Item) string {
+	return string(appendItem(nil, item, true, 0))
+}
+
+// PrintUnformatted renders item as compact JSON.
+func PrintUnformatted(item *Item) string {
+	return string(appendItem(nil, item, false, 0))
+}
+
+// MarshalJSON implements json.Marshaler, so Items can be passed to Marshal
+// or embedded in values encoded with encoding/json.
+func (item *Item) MarshalJSON() ([]byte, error) {
+	return appendItem(nil, item, false, 0), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler with the native parser.
+func (item *Item) UnmarshalJSON(data []byte) error {
+	parsed, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	key := item.Key
+	*item = *parsed
+	item.Key = key
+	return nil
+}
+
+func appendItem(buf []byte, item *Item, format bool, depth int) []byte {
+	if item == nil {
+		return append(buf, "null"...)
+	}
+	switch item.Type {
+	case False:
+		return append(buf, "false"...)
+	case True:
+		return append(buf, "true"...)
+	case Number:
+		if text := item.literal(); text != "" {
+			return append(buf, text...)
+		}
+		return appendNumber(buf, item.ValueDouble)
+	case String:
+		return appendString(buf, item.ValueString)
+	case Raw:
+		return append(buf, item.ValueString...)
+	case Array:
+		buf = append(buf, '[')
+		for i, child := range item.Children {
+			if i > 0 {
+				buf = append(buf, ',')
+				if format {
+					buf = append(buf, ' ')
+				}
+			}
+			buf = appendItem(buf, child, format, depth)
+		}
+		return append(buf, ']')
+	case Object:
+		buf = append(buf, '{')
+		if format {
+			buf = append(buf, '\n')
+		}
+		for i, child := range item.Children {
+			if format {
+				buf = appendTabs(buf, depth+1)
+			}
+			buf = appendString(buf, child.Key)
+			buf = append(buf, ':')
+			if format {
+				buf = append(buf, '\t')
+			}
+			buf = appendItem(buf, child, format, depth+1)
+			if i < len(item.Children)-1 {
+				buf = append(buf, ',')
+			}
+			if format {
+				buf = append(buf, '\n')
+			}
+		}
+		if format {
+			buf = appendTabs(buf, depth)
+		}
+		return append(buf, '}')
+	}
+	return append(buf, "null"...) // Null and Invalid.
+}
+
+func appendTabs(buf []byte, n int) []byte {
+	for i := 0; i < n; i++ {
+		buf = append(buf, '\t')
+	}
+	return buf
+}
+
+// appendNumber writes f the way cJSON does: NaN and infinities become null.
+func appendNumber(buf []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return append(buf, "null"...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, 64)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendString writes s as a quoted JSON string. Invalid UTF-8 is replaced
+// with U+FFFD so the output is always valid JSON.
+func appendString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' && c < utf8.RuneSelf {
+			i++
+			continue
+		}
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r != utf8.RuneError || size != 1 {
+				i += size
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i++
+			start = i
+			continue
+		}
+		buf = append(buf, s[start:i]...)
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		}
+		i++
+		start = i
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
@@ -0,0 +1,385 @@
+package cjsongo
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// minQueryGrain is the smallest subtree, in nodes, worth searching on
+// another goroutine.
+const minQueryGrain = 4 << 10
+
+// Query is a compiled path query. Its syntax extends RFC 6901 JSON Pointer:
+// the query is a sequence of segments, each introduced by '/', and besides
+// the usual reference tokens a segment may select
+//
+//	/*               every element of an array or member of an object
+//	/**              the value itself and all of its descendants
+//	/[?@/path]       every child that has a value at the relative pointer
+//	/[?@/path op v]  every child whose value at the relative pointer
+//	                 compares to the JSON value v with op: ==, !=, <, <=,
+//	                 > or >=
+//
+// so "/store/books/[?@/price < 10]/title" selects the titles of the cheap
+// books and "/**/id" every "id" member in the document. == and != compare
+// any values as Compare does; the ordering operators compare two numbers by
+// their exact literals, or two strings, and are false for other values. The
+// relative pointer ends at the first space or operator character, and "@"
+// alone refers to the child itself. Members named "*" or "**" cannot be
+// selected by name.
+//
+// A Query is safe for concurrent use.
+type Query struct {
+	text     string
+	segments []querySegment
+}
+
+// Match is a value selected by a Query.
+type Match struct {
+	Path string // JSON Pointer to Item from the queried value.
+	Item *Item  // The selected item, shared with the queried tree.
+}
+
+type segmentKind int
+
+const (
+	childSegment segmentKind = iota
+	wildcardSegment
+	descendantSegment
+	filterSegment
+)
+
+type querySegment struct {
+	kind   segmentKind
+	token  string       // Reference token of a childSegment.
+	filter *queryFilter // Predicate of a filterSegment.
+}
+
+// queryFilter tests the value at a relative pointer below a child.
+type queryFilter struct {
+	path  []string
+	op    string // Comparison operator, or "" to test for existence.
+	value *Item
+}
+
+// CompileQuery parses a query for use with Find and FindParallel.
+func CompileQuery(query string) (*Query, error) {
+	q := &Query{text: query}
+	if query == "" {
+		return q, nil
+	}
+	if query[0] != '/' {
+		return nil, fmt.Errorf("invalid query %q: must be empty or start with '/'", query)
+	}
+	for i := 0; i < len(query); {
+		seg, end, err := parseSegment(query, i+1)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query %q: %w", query, err)
+		}
+		q.segments = append(q.segments, seg)
+		i = end
+	}
+	return q, nil
+}
+
+// Find compiles query and returns its matches in item, as Query.Find does.
+func Find(item *Item, query string) ([]Match, error) {
+	q, err := CompileQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return q.Find(item), nil
+}
+
+// String returns the text the query was compiled from.
+func (q *Query) String() string { return q.text }
+
+// Find returns the values in item selected by the query, in document order
+// with each value visited depth first. A value reached along several paths,
+// as with "/**/**", is reported once per path.
+func (q *Query) Find(item *Item) []Match {
+	return q.find(item, 0)
+}
+
+// FindParallel returns the same matches as Find, but searches the children
+// of large arrays and objects expanded by wildcard, descendant and filter
+// segments on several goroutines. Small values are searched sequentially.
+func (q *Query) FindParallel(item *Item) []Match {
+	procs := runtime.GOMAXPROCS(0)
+	nodes := nodeCount(item)
+	grain := max(nodes/(4*procs), minQueryGrain)
+	if procs == 1 || nodes < 2*grain {
+		return q.Find(item)
+	}
+	return q.find(item, grain)
+}
+
+// find runs the query, splitting subtrees of at least grain nodes across
+// goroutines. A zero grain searches sequentially.
+func (q *Query) find(item *Item, grain int) []Match {
+	if item == nil {
+		return nil
+	}
+	s := &querySearch{grain: grain}
+	if grain > 0 {
+		s.sem = make(chan struct{}, runtime.GOMAXPROCS(0))
+	}
+	return s.eval(item, 0, nil, q.segments, nil)
+}
+
+// parseSegment parses the segment starting at query[i] and returns the
+// index of the '/' that ends it, or len(query).
+func parseSegment(query string, i int) (querySegment, int, error) {
+	if strings.HasPrefix(query[i:], "[?") {
+		end, err := filterEnd(query, i+2)
+		if err != nil {
+			return querySegment{}, 0, err
+		}
+		if end+1 < len(query) && query[end+1] != '/' {
+			return querySegment{}, 0, fmt.Errorf("unexpected %q after filter", query[end+1:])
+		}
+		f, err := parseFilter(query[i+2 : end])
+		if err != nil {
+			return querySegment{}, 0, err
+		}
+		return querySegment{kind: filterSegment, filter: f}, end + 1, nil
+	}
+	end := len(query)
+	if j := strings.IndexByte(query[i:], '/'); j >= 0 {
+		end = i + j
+	}
+	switch tok := query[i:end]; tok {
+	case "*":
+		return querySegment{kind: wildcardSegment}, end, nil
+	case "**":
+		return querySegment{kind: descendantSegment}, end, nil
+	default:
+		unescaped, ok := unescapeToken(tok)
+		if !ok {
+			return querySegment{}, 0, fmt.Errorf("bad escape in %q", tok)
+		}
+		return querySegment{kind: childSegment, token: unescaped}, end, nil
+	}
+}
+
+// filterEnd returns the index of the ']' closing the filter whose body
+// starts at query[i], skipping over string literals and nested arrays and
+// objects in the compared value.
+func filterEnd(query string, i int) (int, error) {
+	depth, inString, escaped := 0, false, false
+	for ; i < len(query); i++ {
+		switch c := query[i]; {
+		case escaped:
+			escaped = false
+		case inString:
+			escaped = c == '\\'
+			inString = c != '"'
+		case c == '"':
+			inString = true
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' && depth == 0:
+			return i, nil
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return 0, errors.New("unterminated filter")
+}
+
+// parseFilter parses the body of a "[?...]" segment.
+func parseFilter(body string) (*queryFilter, error) {
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, "@") {
+		return nil, fmt.Errorf("filter %q must start with '@'", body)
+	}
+	end := strings.IndexAny(body, " \t\n\r=!<>")
+	if end < 0 {
+		end = len(body)
+	}
+	path, err := parsePointer(body[1:end])
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", body, err)
+	}
+	f := &queryFilter{path: path}
+	rest := strings.TrimSpace(body[end:])
+	if rest == "" {
+		return f, nil
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(rest, op) {
+			f.op = op
+			break
+		}
+	}
+	if f.op == "" {
+		return nil, fmt.Errorf("filter %q: unknown operator in %q", body, rest)
+	}
+	f.value, err = Parse([]byte(rest[len(f.op):]))
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: bad value: %w", body, err)
+	}
+	return f, nil
+}
+
+// match reports whether child passes the filter.
+func (f *queryFilter) match(child *Item) bool {
+	v := child
+	for _, tok := range f.path {
+		i, ok := childIndex(v, tok)
+		if !ok {
+			return false
+		}
+		v = v.Children[i]
+	}
+	switch f.op {
+	case "":
+		return true
+	case "==":
+		return Compare(v, f.value, true)
+	case "!=":
+		return !Compare(v, f.value, true)
+	}
+	var c int
+	switch {
+	case IsNumber(v) && IsNumber(f.value):
+		c = compareNumbers(v, f.value)
+	case IsString(v) && IsString(f.value):
+		c = strings.Compare(v.ValueString, f.value.ValueString)
+	default:
+		return false
+	}
+	switch f.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// queryPath is the path to a visited item, kept as a list linked towards
+// the root so that subtrees searched concurrently can share their prefix.
+type queryPath struct {
+	parent *queryPath
+	token  string
+}
+
+func (p *queryPath) child(token string) *queryPath {
+	return &queryPath{parent: p, token: token}
+}
+
+// String formats the path as a JSON Pointer.
+func (p *queryPath) String() string {
+	var tokens []string
+	for ; p != nil; p = p.parent {
+		tokens = append(tokens, p.token)
+	}
+	var sb strings.Builder
+	for i := len(tokens) - 1; i >= 0; i-- {
+		sb.WriteByte('/')
+		sb.WriteString(pointerEscaper.Replace(tokens[i]))
+	}
+	return sb.String()
+}
+
+// childToken returns the reference token for the i-th child of item.
+func childToken(item *Item, i int) string {
+	if item.Type == Object {
+		return item.Children[i].Key
+	}
+	return strconv.Itoa(i)
+}
+
+type querySearch struct {
+	grain int
+	sem   chan struct{} // Limits extra goroutines to the number of CPUs; nil searches sequentially.
+}
+
+// eval appends the matches of segs in item, which is reached by path and
+// has nodes nodes, or 0 if that has not been counted.
+func (s *querySearch) eval(item *Item, nodes int, path *queryPath, segs []querySegment, out []Match) []Match {
+	if len(segs) == 0 {
+		return append(out, Match{Path: path.String(), Item: item})
+	}
+	switch seg := segs[0]; seg.kind {
+	case childSegment:
+		if i, ok := childIndex(item, seg.token); ok {
+			out = s.eval(item.Children[i], 0, path.child(childToken(item, i)), segs[1:], out)
+		}
+		return out
+	case wildcardSegment:
+		return s.children(item, nodes, path, segs[1:], nil, out)
+	case descendantSegment:
+		out = s.eval(item, nodes, path, segs[1:], out)
+		return s.children(item, nodes, path, segs, nil, out)
+	default:
+		return s.children(item, nodes, path, segs[1:], seg.filter, out)
+	}
+}
+
+// children appends the matches of segs in each child of item that passes
+// filter, or in every child if filter is nil. Large items are split into
+// groups of children searched concurrently, and the groups' matches joined
+// in order.
+func (s *querySearch) children(item *Item, nodes int, path *queryPath, segs []querySegment, filter *queryFilter, out []Match) []Match {
+	if item.Type != Array && item.Type != Object || len(item.Children) == 0 {
+		return out
+	}
+	if s.sem != nil && nodes == 0 {
+		nodes = nodeCount(item)
+	}
+	if s.sem == nil || nodes < s.grain {
+		seq := &querySearch{}
+		for i, child := range item.Children {
+			if filter == nil || filter.match(child) {
+				out = seq.eval(child, 0, path.child(childToken(item, i)), segs, out)
+			}
+		}
+		return out
+	}
+
+	counts := make([]int, len(item.Children))
+	var groups [][2]int
+	first, size := 0, 0
+	for i, child := range item.Children {
+		counts[i] = nodeCount(child)
+		size += counts[i]
+		if size >= s.grain || i == len(item.Children)-1 {
+			groups = append(groups, [2]int{first, i + 1})
+			first, size = i+1, 0
+		}
+	}
+	parts := make([][]Match, len(groups))
+	var wg sync.WaitGroup
+	for g, group := range groups {
+		run := func() {
+			for i := group[0]; i < group[1]; i++ {
+				child := item.Children[i]
+				if filter == nil || filter.match(child) {
+					parts[g] = s.eval(child, counts[i], path.child(childToken(item, i)), segs, parts[g])
+				}
+			}
+		}
+		select {
+		case s.sem <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() { <-s.sem; wg.Done() }()
+				run()
+			}()
+		default:
+			run() // All CPUs busy: search inline rather than queueing.
+		}
+	}
+	wg.Wait()
+	return slices.Concat(append([][]Match{out}, parts...)...)
+}
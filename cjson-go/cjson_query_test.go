@@ -0,0 +1,214 @@
+package cjsongo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const queryDoc = `{
+	"store": {
+		"books": [
+			{"title": "A", "price": 8.95, "tags": ["x"]},
+			{"title": "B", "price": 12.99, "isbn": "0-553"},
+			{"title": "C]", "price": 8.99, "isbn": "0-395"},
+			{"title": "D", "price": "n/a"}
+		],
+		"bike": {"color": "red", "price": 19.95}
+	},
+	"a/b": {"~": 1},
+	"id": 0
+}`
+
+func TestQueryFind(t *testing.T) {
+	doc, err := Parse([]byte(queryDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query string
+		want  []string // Path=value pairs.
+	}{
+		{"", []string{"=" + PrintUnformatted(doc)}},
+		{"/store/bike/color", []string{`/store/bike/color="red"`}},
+		{"/a~1b/~0", []string{"/a~1b/~0=1"}},
+		{"/store/books/*/title", []string{
+			`/store/books/0/title="A"`, `/store/books/1/title="B"`,
+			`/store/books/2/title="C]"`, `/store/books/3/title="D"`,
+		}},
+		{"/store/*/price", []string{"/store/bike/price=19.95"}},
+		{"/**/price", []string{
+			"/store/books/0/price=8.95", "/store/books/1/price=12.99",
+			"/store/books/2/price=8.99", `/store/books/3/price="n/a"`,
+			"/store/bike/price=19.95",
+		}},
+		{"/**/tags/0", []string{`/store/books/0/tags/0="x"`}},
+		{"/store/books/[?@/price < 10]/title", []string{
+			`/store/books/0/title="A"`, `/store/books/2/title="C]"`,
+		}},
+		{"/store/books/[?@/price>=12.99]/title", []string{`/store/books/1/title="B"`}},
+		{"/store/books/[?@/isbn]/isbn", []string{
+			`/store/books/1/isbn="0-553"`, `/store/books/2/isbn="0-395"`,
+		}},
+		{`/store/books/[?@/title == "C]"]/price`, []string{"/store/books/2/price=8.99"}},
+		{`/store/books/[?@/title != "A"]/price`, []string{
+			"/store/books/1/price=12.99", "/store/books/2/price=8.99", `/store/books/3/price="n/a"`,
+		}},
+		{`/store/books/[?@/price > "m"]/title`, []string{`/store/books/3/title="D"`}},
+		{`/store/books/[?@/tags == ["x"]]/title`, []string{`/store/books/0/title="A"`}},
+		{`/store/[?@ != {"color": "]"}]/price`, []string{"/store/bike/price=19.95"}},
+		{`/**/[?@ == 1]`, []string{"/a~1b/~0=1"}},
+		{"/store/books/9", nil},
+		{"/store/bike/*/x", nil},
+		{"/id/*", nil},
+	}
+	for _, tt := range tests {
+		matches, err := Find(doc, tt.query)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.Path+"="+PrintUnformatted(m.Item))
+			if GetPointer(doc, m.Path) != m.Item {
+				t.Errorf("%s: path %s does not lead to its item", tt.query, m.Path)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestQueryFilterComparesExactNumbers(t *testing.T) {
+	doc, err := Parse([]byte(`[{"id": 9007199254740992}, {"id": 9007199254740993}, {"id": 9.007199254740994e15}, {"id": 1e400}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for query, want := range map[string][]string{
+		"/[?@/id > 9007199254740992]/id":   {"/1/id", "/2/id", "/3/id"},
+		"/[?@/id <= 9007199254740993]/id":  {"/0/id", "/1/id"},
+		"/[?@/id >= 90071992547409930e-1]": {"/1", "/2", "/3"},
+		"/[?@/id < 1e401]/id":              {"/0/id", "/1/id", "/2/id", "/3/id"},
+	} {
+		matches, err := Find(doc, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.Path)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: expected %q, got %q", query, want, got)
+		}
+	}
+}
+
+func TestQueryDescendantsIncludeSelf(t *testing.T) {
+	doc, _ := Parse([]byte(`{"a": {"a": 1}}`))
+	q, err := CompileQuery("/**")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, m := range q.Find(doc) {
+		paths = append(paths, m.Path)
+	}
+	if want := []string{"", "/a", "/a/a"}; !slices.Equal(paths, want) {
+		t.Errorf("expected %q, got %q", want, paths)
+	}
+	if q.String() != "/**" {
+		t.Errorf("unexpected String: %s", q)
+	}
+	if got := q.Find(nil); got != nil {
+		t.Errorf("expected no matches in nil, got %v", got)
+	}
+}
+
+func TestCompileQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"store",
+		"/a~2",
+		"/[?@/price < 10",
+		"/[?@/price < 10]x",
+		"/[?price < 10]",
+		"/[?@/price ~ 10]",
+		"/[?@/price < ten]",
+		"/[?@/a~ == 1]",
+	} {
+		if _, err := CompileQuery(query); err == nil || !strings.Contains(err.Error(), "invalid query") {
+			t.Errorf("%s: expected an invalid query error, got %v", query, err)
+		}
+	}
+}
+
+func TestQueryFindParallel(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(`{"groups": [`)
+	for i := range 200 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `{"id": %d, "items": [`, i)
+		for j := range 50 {
+			if j > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(&sb, `{"n": %d, "tags": {"even": %t}}`, i*50+j, j%2 == 0)
+		}
+		sb.WriteString("]}")
+	}
+	sb.WriteString("]}")
+	doc, err := Parse([]byte(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{
+		"/groups/*/items/[?@/tags/even == true]/n",
+		"/**/n",
+		"/**/[?@/n > 9000]",
+		"/groups/*/id",
+	} {
+		q, err := CompileQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := q.Find(doc)
+		if len(want) == 0 {
+			t.Fatalf("%s: no matches", query)
+		}
+		for _, grain := range []int{1, 7, 100, 1000} {
+			if got := q.find(doc, grain); !slices.Equal(got, want) {
+				t.Errorf("%s: grain %d: got %d matches, expected %d in order", query, grain, len(got), len(want))
+			}
+		}
+		if got := q.FindParallel(doc); !slices.Equal(got, want) {
+			t.Errorf("%s: FindParallel differs from Find", query)
+		}
+	}
+}
+
+func BenchmarkQueryDescendants(b *testing.B) {
+	doc, err := Parse(largeDocument(1 << 14))
+	if err != nil {
+		b.Fatal(err)
+	}
+	q, err := CompileQuery("/**/[?@/score > 100]/id")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Find", func(b *testing.B) {
+		for range b.N {
+			q.Find(doc)
+		}
+	})
+	b.Run("FindParallel", func(b *testing.B) {
+		for range b.N {
+			q.FindParallel(doc)
+		}
+	})
+}
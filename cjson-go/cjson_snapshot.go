@@ -0,0 +1,164 @@
+package cjsongo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+)
+
+// ErrNoPath is returned when a JSON Pointer does not lead to an existing
+// value, or for Set, to an existing parent.
+var ErrNoPath = errors.New("path not found")
+
+// Snapshot is an immutable JSON document. Updates return a new Snapshot
+// that copies only the items on the path to the change and shares every
+// other subtree with the original, so old and new versions can be read
+// concurrently without locks.
+//
+// Items obtained from a Snapshot are shared and must not be modified; use
+// Copy for a tree that may be changed.
+type Snapshot struct {
+	root *Item
+}
+
+// NewSnapshot returns a Snapshot of a deep copy of item, so later changes
+// to item do not affect it.
+func NewSnapshot(item *Item) *Snapshot {
+	return &Snapshot{root: Duplicate(item, true)}
+}
+
+// Root returns the shared root item.
+func (s *Snapshot) Root() *Item { return s.root }
+
+// Get returns the shared item that the JSON Pointer refers to, or nil.
+func (s *Snapshot) Get(pointer string) *Item {
+	return GetPointer(s.root, pointer)
+}
+
+// Copy returns a deep, mutable copy of the document.
+func (s *Snapshot) Copy() *Item {
+	return Duplicate(s.root, true)
+}
+
+// MarshalJSON prints the document without formatting.
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	return s.root.MarshalJSON()
+}
+
+// Set returns a Snapshot in which the value at the JSON Pointer is a copy of
+// value. An existing array element or object member is replaced; a new
+// object member is appended, and the array index "-" appends an element.
+// The parent must already exist.
+func (s *Snapshot) Set(pointer string, value *Item) (*Snapshot, error) {
+	if value == nil {
+		return nil, fmt.Errorf("set %s: nil value", pointer)
+	}
+	return s.update(pointer, Duplicate(value, true))
+}
+
+// Delete returns a Snapshot without the value at the JSON Pointer.
+func (s *Snapshot) Delete(pointer string) (*Snapshot, error) {
+	if pointer == "" {
+		return nil, errors.New("delete: cannot delete the document root")
+	}
+	return s.update(pointer, nil)
+}
+
+// update replaces the value at pointer with value, or deletes it if value
+// is nil.
+func (s *Snapshot) update(pointer string, value *Item) (*Snapshot, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return &Snapshot{root: value}, nil
+	}
+	root, err := updateIn(s.root, tokens, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, pointer)
+	}
+	return &Snapshot{root: root}, nil
+}
+
+// updateIn returns a copy of item with the change applied below it.
+func updateIn(item *Item, tokens []string, value *Item) (*Item, error) {
+	if item == nil || item.Type != Array && item.Type != Object {
+		return nil, ErrNoPath
+	}
+	tok := tokens[0]
+	i, found := childIndex(item, tok)
+	dup := *item
+	if len(tokens) > 1 {
+		if !found {
+			return nil, ErrNoPath
+		}
+		child, err := updateIn(item.Children[i], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		dup.Children = slices.Clone(item.Children)
+		dup.Children[i] = child
+		return &dup, nil
+	}
+
+	switch {
+	case value == nil:
+		if !found {
+			return nil, ErrNoPath
+		}
+		dup.Children = slices.Delete(slices.Clone(item.Children), i, i+1)
+	case found:
+		if item.Type == Object {
+			value.Key = tok
+		}
+		dup.Children = slices.Clone(item.Children)
+		dup.Children[i] = value
+	case item.Type == Object:
+		value.Key = tok
+		dup.Children = append(slices.Clip(item.Children), value)
+	default:
+		if j, ok := arrayIndex(tok, len(item.Children)); !ok || j != len(item.Children) {
+			return nil, ErrNoPath
+		}
+		dup.Children = append(slices.Clip(item.Children), value)
+	}
+	return &dup, nil
+}
+
+// SharedSnapshot publishes the current Snapshot of a document to concurrent
+// readers. Load never blocks; Update applies changes atomically, retrying
+// if another update got in first.
+type SharedSnapshot struct {
+	current atomic.Pointer[Snapshot]
+}
+
+// NewSharedSnapshot returns a SharedSnapshot holding s.
+func NewSharedSnapshot(s *Snapshot) *SharedSnapshot {
+	shared := &SharedSnapshot{}
+	shared.current.Store(s)
+	return shared
+}
+
+// Load returns the current Snapshot.
+func (s *SharedSnapshot) Load() *Snapshot { return s.current.Load() }
+
+// Store replaces the current Snapshot.
+func (s *SharedSnapshot) Store(snap *Snapshot) { s.current.Store(snap) }
+
+// Update derives a new Snapshot from the current one with fn and publishes
+// it. If another update is published while fn runs, fn is called again with
+// the newer Snapshot. An error from fn leaves the current Snapshot in place.
+func (s *SharedSnapshot) Update(fn func(*Snapshot) (*Snapshot, error)) (*Snapshot, error) {
+	for {
+		old := s.current.Load()
+		next, err := fn(old)
+		if err != nil {
+			return nil, err
+		}
+		if s.current.CompareAndSwap(old, next) {
+			return next, nil
+		}
+	}
+}
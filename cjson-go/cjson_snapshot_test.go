@@ -0,0 +1,144 @@
+package cjsongo
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotUpdates(t *testing.T) {
+	doc, err := Parse([]byte(`{"server": {"host": "a", "ports": [80, 443]}, "limits": {"rps": 10}, "a/b": {"~": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := NewSnapshot(doc)
+	AddNumberToObject(doc, "late", 1)
+	if v1.Get("/late") != nil {
+		t.Fatal("snapshot sees changes to the item it was made from")
+	}
+
+	v2, err := v1.Set("/server/ports/-", CreateNumber(8080))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v3, err := v2.Set("/server/host", CreateString("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v4, err := v3.Delete("/limits/rps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v5, err := v4.Set("/a~1b/~0", CreateTrue())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`{"server":{"host":"a","ports":[80,443]},"limits":{"rps":10},"a/b":{"~":1}}`,
+		`{"server":{"host":"a","ports":[80,443,8080]},"limits":{"rps":10},"a/b":{"~":1}}`,
+		`{"server":{"host":"b","ports":[80,443,8080]},"limits":{"rps":10},"a/b":{"~":1}}`,
+		`{"server":{"host":"b","ports":[80,443,8080]},"limits":{},"a/b":{"~":1}}`,
+		`{"server":{"host":"b","ports":[80,443,8080]},"limits":{},"a/b":{"~":true}}`,
+	}
+	for i, s := range []*Snapshot{v1, v2, v3, v4, v5} {
+		if got := PrintUnformatted(s.Root()); got != want[i] {
+			t.Errorf("version %d: expected %s, got %s", i+1, want[i], got)
+		}
+	}
+
+	// Unchanged subtrees are shared, changed paths are copied.
+	if v2.Get("/limits") != v1.Get("/limits") || v3.Get("/server/ports") != v2.Get("/server/ports") {
+		t.Error("expected unchanged subtrees to be shared")
+	}
+	if v2.Get("/server") == v1.Get("/server") || v2.Root() == v1.Root() {
+		t.Error("expected the updated path to be copied")
+	}
+
+	for _, p := range []string{"/missing/x", "/server/ports/5", "/server/ports/01", "/server/host/x", "nope"} {
+		if _, err := v5.Set(p, CreateNull()); err == nil {
+			t.Errorf("%s: expected error", p)
+		}
+	}
+	if _, err := v5.Delete("/server/nothing"); !errors.Is(err, ErrNoPath) {
+		t.Errorf("expected ErrNoPath, got %v", err)
+	}
+	root, err := v5.Set("", CreateArray())
+	if err != nil || !IsArray(root.Root()) {
+		t.Errorf("expected the root to be replaced, got %v", err)
+	}
+
+	mutable := v5.Copy()
+	GetPointer(mutable, "/server").Children = nil
+	if GetArraySize(v5.Get("/server")) != 2 {
+		t.Error("Copy shares items with the snapshot")
+	}
+}
+
+// TestSharedSnapshotConcurrency is meant to be run with -race: readers walk
+// whatever version is current while writers publish new ones.
+func TestSharedSnapshotConcurrency(t *testing.T) {
+	doc, _ := Parse([]byte(`{"version": 0, "log": [], "static": {"name": "cfg", "list": [1, 2, 3]}}`))
+	shared := NewSharedSnapshot(NewSnapshot(doc))
+	static := shared.Load().Get("/static")
+
+	const writers, updates, readers = 2, 200, 4
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	errs := make(chan error, readers)
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				s := shared.Load()
+				version := int(GetNumberValue(s.Get("/version")))
+				if n := GetArraySize(s.Get("/log")); n != version {
+					errs <- fmt.Errorf("version %d has %d log entries", version, n)
+					return
+				}
+				if s.Get("/static") != static || PrintUnformatted(s.Root()) == "" {
+					errs <- errors.New("static subtree was copied")
+					return
+				}
+			}
+		}()
+	}
+	var writersWG sync.WaitGroup
+	for w := range writers {
+		writersWG.Add(1)
+		go func() {
+			defer writersWG.Done()
+			for i := range updates {
+				_, err := shared.Update(func(s *Snapshot) (*Snapshot, error) {
+					version := GetNumberValue(s.Get("/version"))
+					next, err := s.Set("/log/-", CreateString(fmt.Sprintf("w%d-%d", w, i)))
+					if err != nil {
+						return nil, err
+					}
+					return next.Set("/version", CreateNumber(version+1))
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	writersWG.Wait()
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if v := GetNumberValue(shared.Load().Get("/version")); v != writers*updates {
+		t.Errorf("expected %d updates, got %v", writers*updates, v)
+	}
+}
@@ -0,0 +1,337 @@
+package cjsongo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"strings"
+)
+
+// Decoder reads a sequence of JSON values, such as NDJSON or whitespace
+// separated documents, from a reader. Input is read incrementally and only
+// the value being decoded is buffered, so memory use is bounded by the
+// largest value rather than the stream.
+//
+// The limits in the Decoder's ParseOptions apply to each value, with
+// MaxInputBytes bounding the size of one value; RequireEnd is ignored.
+type Decoder struct {
+	r      io.Reader
+	opts   ParseOptions
+	buf    []byte
+	pos    int   // Start of the unconsumed data in buf.
+	offset int64 // Stream offset of buf[0].
+	line   int   // Line of buf[pos].
+	column int   // Byte column of buf[pos].
+	err    error // Sticky read or limit error.
+}
+
+// NewDecoder returns a Decoder reading from r with the given limits.
+func NewDecoder(r io.Reader, opts ParseOptions) *Decoder {
+	return &Decoder{r: r, opts: opts, line: 1, column: 1}
+}
+
+const streamBufferSize = 32 << 10
+
+// rawValue is the text of one value cut from the stream, with the location
+// of its first byte.
+type rawValue struct {
+	data         []byte
+	offset       int64
+	line, column int
+}
+
+// Decode returns the next value in the stream, or io.EOF once the stream is
+// exhausted. Malformed values are reported as a *ParseError located in the
+// stream, after which decoding resumes with the following value. Read errors
+// and exceeded MaxInputBytes limits are returned by every later call too.
+func (d *Decoder) Decode() (*Item, error) {
+	v, err := d.next(false)
+	if err != nil {
+		return nil, err
+	}
+	return v.parse(d.opts)
+}
+
+// All returns an iterator over the remaining values in the stream. It stops
+// after the first error, which it yields with a nil Item.
+func (d *Decoder) All() iter.Seq2[*Item, error] {
+	return func(yield func(*Item, error) bool) {
+		for {
+			item, err := d.Decode()
+			if err == io.EOF {
+				return
+			}
+			if !yield(item, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// InputOffset returns the stream offset just past the last decoded value.
+func (d *Decoder) InputOffset() int64 {
+	return d.offset + int64(d.pos)
+}
+
+// next cuts the next value from the stream. Unless keep is set the returned
+// bytes alias the buffer and are only valid until the following call.
+func (d *Decoder) next(keep bool) (rawValue, error) {
+	for {
+		i := d.pos
+		for i < len(d.buf) && isSpace(d.buf[i]) {
+			i++
+		}
+		d.advance(i)
+		if d.pos < len(d.buf) {
+			break
+		}
+		if !d.refill() {
+			return rawValue{}, d.err
+		}
+	}
+	var s streamScanner
+	scanned := 0
+	for {
+		end, ok := s.scan(d.buf, d.pos+scanned)
+		scanned = end - d.pos
+		if limit := d.opts.MaxInputBytes; limit > 0 && scanned > limit {
+			p := &parser{data: d.buf[d.pos:]}
+			d.err = d.locate(p.limitError(limit, ErrMaxInputBytes))
+			d.pos = len(d.buf) // The rest of the value cannot be found without buffering it.
+			return rawValue{}, d.err
+		}
+		if !ok && !d.refill() {
+			if d.err != io.EOF {
+				return rawValue{}, d.err
+			}
+			ok = true // The parser reports what an unfinished value is missing.
+		}
+		if ok {
+			return d.take(d.pos+scanned, keep), nil
+		}
+	}
+}
+
+// take consumes the value ending at end.
+func (d *Decoder) take(end int, keep bool) rawValue {
+	v := rawValue{data: d.buf[d.pos:end], offset: d.InputOffset(), line: d.line, column: d.column}
+	if keep {
+		v.data = bytes.Clone(v.data)
+	}
+	d.advance(end)
+	return v
+}
+
+// advance consumes buf up to i, keeping the line and column current.
+func (d *Decoder) advance(i int) {
+	consumed := d.buf[d.pos:i]
+	if n := bytes.Count(consumed, []byte{'\n'}); n > 0 {
+		d.line += n
+		d.column = len(consumed) - bytes.LastIndexByte(consumed, '\n')
+	} else {
+		d.column += len(consumed)
+	}
+	d.pos = i
+}
+
+// refill reads more input after the data in buf. When buf is full the
+// unconsumed data is moved to the front, into a larger buffer if it fills
+// more than half. It reports false and records d.err once the reader fails
+// or is exhausted.
+func (d *Decoder) refill() bool {
+	if d.err != nil {
+		return false
+	}
+	if len(d.buf) == cap(d.buf) {
+		buf := d.buf
+		if n := len(d.buf) - d.pos; n > cap(d.buf)/2 || cap(d.buf) == 0 {
+			buf = make([]byte, 0, max(2*cap(d.buf), streamBufferSize))
+		}
+		d.buf = append(buf[:0], d.buf[d.pos:]...)
+		d.offset += int64(d.pos)
+		d.pos = 0
+	}
+	n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+	d.buf = d.buf[:len(d.buf)+n]
+	if err != nil {
+		d.err = err
+		return n > 0
+	}
+	return true
+}
+
+// locate moves a *ParseError raised within the value starting at the read
+// position to its place in the stream.
+func (d *Decoder) locate(err error) error {
+	return rawValue{offset: d.InputOffset(), line: d.line, column: d.column}.locate(err)
+}
+
+func (v rawValue) locate(err error) error {
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		if pe.Line == 1 {
+			pe.Column += v.column - 1
+		}
+		pe.Line += v.line - 1
+		pe.Offset += int(v.offset)
+	}
+	return err
+}
+
+func (v rawValue) parse(opts ParseOptions) (*Item, error) {
+	opts.MaxInputBytes = 0 // Checked while scanning.
+	p := newParser(v.data, opts)
+	item, err := p.parseRoot(true)
+	if err != nil {
+		return nil, v.locate(err)
+	}
+	return item, nil
+}
+
+// streamScanner finds where a value ends without parsing it. Its state
+// survives between calls so that a value split across reads is scanned only
+// once.
+type streamScanner struct {
+	depth    int
+	inString bool
+	escaped  bool
+	scalar   bool
+}
+
+// scan continues scanning data at i. It returns the end of the value and
+// true once the value is complete, or len(data) and false if more input is
+// needed.
+func (s *streamScanner) scan(data []byte, i int) (int, bool) {
+	for ; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case s.inString:
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				if s.depth == 0 {
+					return i + 1, true
+				}
+			}
+		case s.scalar:
+			if isSpace(c) || strings.IndexByte(`,:[]{}"`, c) >= 0 {
+				return i, true
+			}
+		case c == '"':
+			s.inString = true
+		case c == '{' || c == '[':
+			s.depth++
+		case c == '}' || c == ']':
+			s.depth--
+			if s.depth <= 0 {
+				return i + 1, true
+			}
+		case s.depth == 0 && !isSpace(c):
+			s.scalar = true
+		}
+	}
+	return len(data), false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// StreamOptions configures DecodeStream.
+type StreamOptions struct {
+	// ParseOptions holds the limits applied to each value, as for a
+	// Decoder.
+	ParseOptions
+
+	// Workers is the number of goroutines parsing values concurrently.
+	// Values are still delivered in stream order. Zero or one parses on the
+	// calling goroutine. With several workers each uses an Arena of its own
+	// when ParseOptions.Arena is set.
+	Workers int
+}
+
+// DecodeStream calls fn with each value read from r, in order, until the
+// stream ends, and returns nil at the end of the stream. It stops at the
+// first error from decoding or from fn and returns it.
+func DecodeStream(r io.Reader, opts StreamOptions, fn func(*Item) error) error {
+	d := NewDecoder(r, opts.ParseOptions)
+	if opts.Workers <= 1 {
+		for item, err := range d.All() {
+			if err != nil {
+				return err
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	type result struct {
+		item *Item
+		err  error
+	}
+	type job struct {
+		v   rawValue
+		out chan result
+	}
+	done := make(chan struct{})
+	defer close(done)
+	work := make(chan job)
+	order := make(chan chan result, 2*opts.Workers)
+	for range opts.Workers {
+		go func() {
+			parseOpts := opts.ParseOptions
+			if parseOpts.Arena != nil {
+				parseOpts.Arena = new(Arena)
+			}
+			for j := range work {
+				item, err := j.v.parse(parseOpts)
+				j.out <- result{item, err}
+			}
+		}()
+	}
+	go func() {
+		defer close(order)
+		defer close(work)
+		for {
+			out := make(chan result, 1)
+			v, err := d.next(true)
+			if err != nil {
+				if err != io.EOF {
+					out <- result{err: err}
+					select {
+					case order <- out:
+					case <-done:
+					}
+				}
+				return
+			}
+			select {
+			case order <- out:
+			case <-done:
+				return
+			}
+			select {
+			case work <- job{v, out}:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for out := range order {
+		res := <-out
+		if res.err != nil {
+			return res.err
+		}
+		if err := fn(res.item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package cjsongo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecoderValues(t *testing.T) {
+	in := "{\"a\": 1}\n[1, 2]\n\"three\" 4 true\r\nnull{\"b\":[]}-5.5e1 []"
+	want := []string{`{"a":1}`, `[1,2]`, `"three"`, `4`, `true`, `null`, `{"b":[]}`, `-5.5e1`, `[]`}
+	for _, r := range []io.Reader{strings.NewReader(in), iotest.OneByteReader(strings.NewReader(in))} {
+		d := NewDecoder(r, ParseOptions{})
+		var got []string
+		for item, err := range d.All() {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, PrintUnformatted(item))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		if d.InputOffset() != int64(len(in)) {
+			t.Errorf("expected offset %d, got %d", len(in), d.InputOffset())
+		}
+		if _, err := d.Decode(); err != io.EOF {
+			t.Errorf("expected io.EOF after the last value, got %v", err)
+		}
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	d := NewDecoder(iotest.OneByteReader(strings.NewReader("{\"a\": 1}\n  {\"b\": x}\n[3] [4")), ParseOptions{})
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := d.Decode()
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Offset != 17 || pe.Line != 2 || pe.Column != 9 {
+		t.Fatalf("expected error at offset 17, 2:9, got %v", err)
+	}
+	if item, err := d.Decode(); err != nil || PrintUnformatted(item) != "[3]" {
+		t.Errorf("expected decoding to resume after a syntax error, got %v, %v", item, err)
+	}
+	if _, err := d.Decode(); !errors.As(err, &pe) || pe.Offset != 26 || pe.Expected != "',' or ']'" {
+		t.Errorf("expected unfinished array error at 26, got %v", err)
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	readErr := errors.New("boom")
+	d = NewDecoder(io.MultiReader(strings.NewReader("1 2 "), iotest.ErrReader(readErr)), ParseOptions{})
+	var n int
+	for _, err := range d.All() {
+		if err != nil {
+			if !errors.Is(err, readErr) {
+				t.Errorf("expected read error, got %v", err)
+			}
+			break
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected 2 values before the read error, got %d", n)
+	}
+}
+
+func TestDecoderLimits(t *testing.T) {
+	in := `[1, 2] ["` + strings.Repeat("x", 100) + `"] [3]`
+	d := NewDecoder(strings.NewReader(in), ParseOptions{MaxInputBytes: 50, MaxItems: 3})
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := d.Decode()
+	var pe *ParseError
+	if !errors.Is(err, ErrMaxInputBytes) || !errors.As(err, &pe) || pe.Offset != 57 {
+		t.Fatalf("expected ErrMaxInputBytes at 57, got %v", err)
+	}
+	if _, err := d.Decode(); !errors.Is(err, ErrMaxInputBytes) {
+		t.Errorf("expected the limit error to persist, got %v", err)
+	}
+	d = NewDecoder(strings.NewReader(`[1, 2] [1, 2, 3]`), ParseOptions{MaxItems: 3})
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decode(); !errors.Is(err, ErrMaxItems) {
+		t.Errorf("expected ErrMaxItems for the second value, got %v", err)
+	}
+}
+
+// ndjson generates n lines of NDJSON without holding them in memory.
+type ndjson struct {
+	n, i int
+	line []byte
+}
+
+func (r *ndjson) Read(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		if len(r.line) == 0 {
+			if r.i == r.n {
+				if written == 0 {
+					return 0, io.EOF
+				}
+				break
+			}
+			r.line = fmt.Appendf(nil, `{"id": %d, "name": "value %d", "tags": ["a", "b"]}`+"\n", r.i, r.i)
+			r.i++
+		}
+		c := copy(b[written:], r.line)
+		r.line = r.line[c:]
+		written += c
+	}
+	return written, nil
+}
+
+func TestDecoderBoundedMemory(t *testing.T) {
+	d := NewDecoder(&ndjson{n: 100000}, ParseOptions{})
+	n := 0
+	for item, err := range d.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if GetNumberValue(GetObjectItem(item, "id")) != float64(n) {
+			t.Fatalf("value %d out of order", n)
+		}
+		n++
+	}
+	if n != 100000 {
+		t.Errorf("expected 100000 values, got %d", n)
+	}
+	if cap(d.buf) > streamBufferSize {
+		t.Errorf("buffer grew to %d bytes for small values", cap(d.buf))
+	}
+
+	big := `"` + strings.Repeat("y", 3*streamBufferSize) + `"`
+	d = NewDecoder(strings.NewReader("1 "+big+" 2"), ParseOptions{})
+	var got []int
+	for item, err := range d.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, len(PrintUnformatted(item)))
+	}
+	if fmt.Sprint(got) != fmt.Sprint([]int{1, len(big), 1}) {
+		t.Errorf("unexpected value lengths %v", got)
+	}
+}
+
+func TestDecodeStreamWorkers(t *testing.T) {
+	for _, opts := range []StreamOptions{{}, {Workers: 4}, {Workers: 3, ParseOptions: ParseOptions{Arena: new(Arena)}}} {
+		n := 0
+		err := DecodeStream(&ndjson{n: 5000}, opts, func(item *Item) error {
+			if GetNumberValue(GetObjectItem(item, "id")) != float64(n) {
+				return fmt.Errorf("value %d out of order", n)
+			}
+			n++
+			return nil
+		})
+		if err != nil || n != 5000 {
+			t.Errorf("workers %d: %d values, %v", opts.Workers, n, err)
+		}
+	}
+
+	in := `1 2 {"bad": } 4 5`
+	var got []string
+	err := DecodeStream(strings.NewReader(in), StreamOptions{Workers: 4}, func(item *Item) error {
+		got = append(got, PrintUnformatted(item))
+		return nil
+	})
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Offset != 12 || fmt.Sprint(got) != "[1 2]" {
+		t.Errorf("expected values before the error and then the error at 12, got %v, %v", got, err)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = DecodeStream(&ndjson{n: 1000}, StreamOptions{Workers: 4}, func(*Item) error {
+		calls++
+		if calls == 10 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || calls != 10 {
+		t.Errorf("expected callback error after 10 calls, got %v after %d", err, calls)
+	}
+}
@@ -29,3 +29,50 @@ func TestUnmarshalStream(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestUnmarshalAnyRoot(t *testing.T) {
+	for in, typ := range map[string]Type{
+		`[1, 2]`:      Array,
+		`"x"`:         String,
+		` 42 `:        Number,
+		`true`:        True,
+		`null`:        Null,
+		`{"a": [{}]}`: Object,
+	} {
+		item, err := Unmarshal([]byte(in))
+		if err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if item.Type != typ {
+			t.Errorf("%s: expected %v, got %v", in, typ, item.Type)
+		}
+		if _, err := UnmarshalParallel([]byte(in)); err != nil {
+			t.Errorf("%s: UnmarshalParallel: %v", in, err)
+		}
+		if _, err := UnmarshalStream(bytes.NewReader([]byte(in))); err != nil {
+			t.Errorf("%s: UnmarshalStream: %v", in, err)
+		}
+	}
+}
+
+func TestMarshalItem(t *testing.T) {
+	in := `{"z":1,"a":[true,false,null,"q\"\n\u0001é"],"m":{"k":-2.5}}`
+	item, err := Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != in {
+		t.Errorf("expected %s, got %s", in, out)
+	}
+	want := "{\n\t\"z\":\t1,\n\t\"a\":\t[true, false, null, \"q\\\"\\n\\u0001é\"],\n\t\"m\":\t{\n\t\t\"k\":\t-2.5\n\t}\n}"
+	if got := Print(item); got != want {
+		t.Errorf("unexpected formatted output:\n%s", got)
+	}
+	if got := Print(&Item{Type: Object}); got != "{\n}" {
+		t.Errorf("unexpected empty object output %q", got)
+	}
+}
@@ -2,12 +2,19 @@
 package jsmngo
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // TokenType represents the type of JSON token.
@@ -39,6 +46,12 @@ type Parser struct {
 	toknext  int // Next token to allocate.
 	toksuper int // Parent token index.
 	tokens   []Token
+
+	disallowDupKeys bool // Reject objects that repeat a key.
+
+	trackLines bool  // Record line starts while tokenizing.
+	lines      []int // Byte offsets at which each line starts.
+	src        []byte
 }
 
 // NewParser creates a new parser with space for numTokens.
@@ -53,6 +66,10 @@ func (p *Parser) Parse(json []byte) (int, error) {
 	p.pos = 0
 	p.toknext = 0
 	p.toksuper = -1
+	if p.trackLines {
+		p.src = json
+		p.lines = append(p.lines[:0], 0)
+	}
 
 	for p.pos < len(json) {
 		c := json[p.pos]
@@ -85,6 +102,9 @@ func (p *Parser) Parse(json []byte) (int, error) {
 			continue
 		case '\t', '\r', '\n', ' ':
 			p.pos++
+			if c == '\n' && p.trackLines {
+				p.lines = append(p.lines, p.pos)
+			}
 			continue
 		case ':':
 			p.pos++
@@ -112,9 +132,36 @@ func (p *Parser) Parse(json []byte) (int, error) {
 	if p.toksuper != -1 {
 		return 0, errors.New("unclosed object or array")
 	}
+	if p.disallowDupKeys {
+		if err := CheckDuplicateKeys(json, p.Tokens()); err != nil {
+			return 0, err
+		}
+	}
 	return p.toknext, nil
 }
 
+// DisallowDuplicateKeys makes Parse fail with a *DuplicateKeyError when any
+// object in the input contains the same key more than once.
+func (p *Parser) DisallowDuplicateKeys() {
+	p.disallowDupKeys = true
+}
+
+// TrackLines makes Parse record line starts so that LineIndex can map token
+// offsets to line and column positions without rescanning the input.
+func (p *Parser) TrackLines() {
+	p.trackLines = true
+}
+
+// LineIndex returns the line index recorded by the last Parse call. It returns
+// nil unless TrackLines was enabled. The index stays valid after the parser is
+// reused.
+func (p *Parser) LineIndex() *LineIndex {
+	if !p.trackLines || p.lines == nil {
+		return nil
+	}
+	return &LineIndex{src: p.src, starts: slices.Clone(p.lines)}
+}
+
 // Tokens returns the parsed tokens.
 func (p *Parser) Tokens() []Token {
 	return p.tokens[:p.toknext]
@@ -142,6 +189,9 @@ func (p *Parser) parseString(json []byte) error {
 			if err := p.allocToken(tok); err != nil {
 				return err
 			}
+			if p.trackLines {
+				p.markLines(json[tok.Start:tok.End], tok.Start)
+			}
 			p.pos++
 			return nil
 		}
@@ -154,6 +204,157 @@ func (p *Parser) parseString(json []byte) error {
 	return errors.New("unclosed string")
 }
 
+// markLines records raw newlines inside a string body, which JSON forbids but
+// the tokenizer tolerates.
+func (p *Parser) markLines(body []byte, base int) {
+	for {
+		i := bytes.IndexByte(body, '\n')
+		if i < 0 {
+			return
+		}
+		p.lines = append(p.lines, base+i+1)
+		body, base = body[i+1:], base+i+1
+	}
+}
+
+// skipToken returns the index of the first token after the subtree rooted at i.
+func skipToken(tokens []Token, i int) int {
+	pending := 1
+	for pending > 0 && i < len(tokens) {
+		pending += tokens[i].Size - 1
+		i++
+	}
+	return i
+}
+
+// pointer returns the JSON Pointer (RFC 6901) of token idx. For object keys
+// and values it names the member; for array elements, the element index.
+func pointer(json []byte, tokens []Token, idx int) string {
+	var segs []string
+	for idx >= 0 && idx < len(tokens) {
+		parent := tokens[idx].ParentIdx
+		if parent < 0 {
+			break
+		}
+		n, key := 0, idx
+		for c := parent + 1; c < idx && c < len(tokens); c = skipToken(tokens, c) {
+			key = c
+			n++
+		}
+		switch {
+		case tokens[parent].Type == Array:
+			segs = append(segs, strconv.Itoa(n))
+		case n%2 == 1:
+			segs = append(segs, keyText(json, tokens[key]))
+		default:
+			segs = append(segs, keyText(json, tokens[idx]))
+		}
+		idx = parent
+	}
+	var b strings.Builder
+	for i := len(segs) - 1; i >= 0; i-- {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(segs[i]))
+	}
+	return b.String()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// keyText returns the unescaped text of a key token, falling back to the raw
+// bytes when the escapes are malformed.
+func keyText(json []byte, tok Token) string {
+	raw := json[tok.Start:tok.End]
+	if s, err := unescape(raw); err == nil {
+		return string(s)
+	}
+	return string(raw)
+}
+
+// unescape decodes the JSON escape sequences in raw string contents (without
+// the surrounding quotes). Input without a backslash is returned as is.
+func unescape(raw []byte) ([]byte, error) {
+	i := 0
+	for i < len(raw) && raw[i] != '\\' {
+		i++
+	}
+	if i == len(raw) {
+		return raw, nil
+	}
+	out := make([]byte, i, len(raw))
+	copy(out, raw[:i])
+	for i < len(raw) {
+		c := raw[i]
+		if c != '\\' {
+			out = append(out, c)
+			i++
+			continue
+		}
+		if i+1 >= len(raw) {
+			return nil, errors.New("invalid escape at end of string")
+		}
+		switch raw[i+1] {
+		case '"', '\\', '/':
+			out = append(out, raw[i+1])
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'u':
+			r, ok := hex4(raw, i+2)
+			if !ok {
+				return nil, errors.New("invalid \\u escape")
+			}
+			i += 6
+			if utf16.IsSurrogate(r) {
+				if i+1 < len(raw) && raw[i] == '\\' && raw[i+1] == 'u' {
+					r2, ok := hex4(raw, i+2)
+					if dec := utf16.DecodeRune(r, r2); ok && dec != utf8.RuneError {
+						out = utf8.AppendRune(out, dec)
+						i += 6
+						continue
+					}
+				}
+				r = utf8.RuneError
+			}
+			out = utf8.AppendRune(out, r)
+			continue
+		default:
+			return nil, fmt.Errorf("invalid escape character %q", raw[i+1])
+		}
+		i += 2
+	}
+	return out, nil
+}
+
+// hex4 decodes the four hex digits starting at raw[i].
+func hex4(raw []byte, i int) (rune, bool) {
+	if i+4 > len(raw) {
+		return 0, false
+	}
+	var r rune
+	for _, c := range raw[i : i+4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
+
 func (p *Parser) parsePrimitive(json []byte) error {
 	tok := Token{Type: Primitive, Start: p.pos, End: -1, ParentIdx: p.toksuper}
 	for p.pos < len(json) {
@@ -173,125 +374,186 @@ func (p *Parser) parsePrimitive(json []byte) error {
 	return nil
 }
 
-// ParseParallel tokenizes JSON in parallel across chunks for improved performance.
+// ParseParallel tokenizes JSON in parallel across chunks for improved
+// performance. The children of a top-level array or object are divided into
+// chunks at their separating commas, each chunk is tokenized on its own
+// goroutine, and the tokens are joined under the root, so the result matches
+// Parse. Other inputs are tokenized sequentially.
 func ParseParallel(json []byte, numTokens int) ([]Token, error) {
-	if len(json) < 512 { // Fallback for small JSON to avoid invalid chunks.
-		p := NewParser(numTokens)
-		_, err := p.Parse(json)
-		if err != nil {
-			return nil, err
-		}
-		return p.Tokens(), nil
+	if len(json) < 512 { // Fallback for small JSON, which is not worth splitting.
+		return parseSequential(json, numTokens)
 	}
-
 	numWorkers := runtime.NumCPU()
 	if numWorkers > 4 {
 		numWorkers = 4 // Cap for simplicity.
 	}
-	chunkSize := len(json) / numWorkers
-	if chunkSize == 0 {
-		chunkSize = len(json)
-		numWorkers = 1
+	return parseParallel(json, numTokens, numWorkers)
+}
+
+func parseSequential(json []byte, numTokens int) ([]Token, error) {
+	p := NewParser(numTokens)
+	if _, err := p.Parse(json); err != nil {
+		return nil, err
 	}
+	return p.Tokens(), nil
+}
 
-	var wg sync.WaitGroup
-	results := make([][]Token, numWorkers)
-	errs := make(chan error, numWorkers)
+// parseParallel tokenizes json in up to numWorkers chunks.
+func parseParallel(json []byte, numTokens, numWorkers int) ([]Token, error) {
+	start, end, cuts, ok := splitChildren(json, numWorkers)
+	if !ok || len(cuts) == 0 {
+		return parseSequential(json, numTokens)
+	}
+
+	// Chunk i holds the children between bounds[i] and bounds[i+1]; the cut
+	// commas themselves carry no tokens.
+	bounds := make([]int, 0, len(cuts)+2)
+	bounds = append(bounds, start+1)
+	for _, c := range cuts {
+		bounds = append(bounds, c+1)
+	}
+	bounds = append(bounds, end)
 
-	for i := 0; i < numWorkers; i++ {
+	var wg sync.WaitGroup
+	results := make([][]Token, len(bounds)-1)
+	errs := make([]error, len(bounds)-1)
+	for i := range results {
 		wg.Add(1)
-		start := i * chunkSize
-		end := start + chunkSize
-		if i == numWorkers-1 {
-			end = len(json)
-		}
 		go func(i int, chunk []byte) {
 			defer wg.Done()
-			p := NewParser(numTokens) // Use full numTokens per to avoid overflow.
-			_, err := p.Parse(chunk)
-			if err != nil {
-				errs <- err
-				return
-			}
-			results[i] = p.Tokens()
-		}(i, json[start:end])
+			results[i], errs[i] = parseSequential(chunk, numTokens) // Use full numTokens per chunk to avoid overflow.
+		}(i, json[bounds[i]:bounds[i+1]])
 	}
-
 	wg.Wait()
-	select {
-	case err := <-errs:
-		return nil, err
-	default:
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Merge results (naive concat; note limitation in README for real use).
-	var merged []Token
+	total := 1
 	for _, res := range results {
-		merged = append(merged, res...)
+		total += len(res)
+	}
+	if total > numTokens {
+		return nil, errors.New("token overflow: too many tokens")
+	}
+	root := Token{Type: Array, Start: start, End: end + 1, ParentIdx: -1}
+	if json[start] == '{' {
+		root.Type = Object
 	}
+	merged := make([]Token, 1, total)
+	for i, res := range results {
+		base := len(merged)
+		for _, tok := range res {
+			tok.Start += bounds[i]
+			tok.End += bounds[i]
+			if tok.ParentIdx < 0 {
+				tok.ParentIdx = 0
+				root.Size++
+			} else {
+				tok.ParentIdx += base
+			}
+			merged = append(merged, tok)
+		}
+	}
+	merged[0] = root
 	return merged, nil
 }
 
-// ParseStream tokenizes JSON from an io.Reader incrementally during I/O.
-func ParseStream(r io.Reader, numTokens int) ([]Token, error) {
-	dec := json.NewDecoder(r)
-	p := NewParser(numTokens)
-	pos := 0 // Track approximate position.
-	for {
-		tok, err := dec.Token()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("decoder error: %w", err)
-		}
-		ourTok := Token{Start: pos, End: pos + 1, ParentIdx: p.toksuper} // Stub positions; enhance with dec.Offset().
-		switch v := tok.(type) {
-		case json.Delim:
-			switch v {
-			case '{':
-				ourTok.Type = Object
-			case '[':
-				ourTok.Type = Array
-			case '}', ']':
-				if p.toksuper != -1 {
-					p.tokens[p.toksuper].End = pos
-					p.toksuper = p.tokens[p.toksuper].ParentIdx
+// splitChildren finds the top-level array or object in json and up to n-1
+// commas between its children that divide it into chunks of similar size.
+// It reports false unless json is a single well-nested container surrounded
+// only by whitespace.
+func splitChildren(json []byte, n int) (start, end int, cuts []int, ok bool) {
+	start = 0
+	for start < len(json) && isSpace(json[start]) {
+		start++
+	}
+	if start == len(json) || json[start] != '[' && json[start] != '{' {
+		return 0, 0, nil, false
+	}
+	depth, inString, escaped := 0, false, false
+	next := start + len(json[start:])/n
+	for i := start; i < len(json); i++ {
+		c := json[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			escaped = c == '\\'
+			inString = c != '"'
+		case c == '"':
+			inString = true
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+			if depth == 0 {
+				for j := i + 1; j < len(json); j++ {
+					if !isSpace(json[j]) {
+						return 0, 0, nil, false
+					}
 				}
-				pos++
-				continue
+				return start, i, cuts, true
 			}
-		case string:
-			ourTok.Type = String
-			ourTok.End = pos + len(v)
-		default: // Primitives.
-			ourTok.Type = Primitive
+		case c == ',' && depth == 1 && i >= next && len(cuts) < n-1:
+			cuts = append(cuts, i)
+			next = i + len(json[start:])/n
 		}
-		if err := p.allocToken(ourTok); err != nil {
-			return nil, err
+	}
+	return 0, 0, nil, false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// ParseStream tokenizes JSON from an io.Reader incrementally during I/O. Reads
+// overlap with tokenizing through ParseStreamPipeline, and the tokens of each
+// top-level value are joined with offsets rebased to the whole stream, so the
+// result matches Parse on the full input.
+func ParseStream(r io.Reader, numTokens int) ([]Token, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := NewParser(numTokens)
+	p.toksuper = -1
+	for v := range ParseStreamPipeline(ctx, r, PipelineOptions{}) {
+		if v.Err != nil {
+			return nil, fmt.Errorf("stream error: %w", v.Err)
 		}
-		if ourTok.Type == Object || ourTok.Type == Array {
-			p.toksuper = p.toknext - 1
+		base := p.toknext
+		for _, tok := range v.Tokens {
+			tok.Start += int(v.Offset)
+			tok.End += int(v.Offset)
+			if tok.ParentIdx >= 0 {
+				tok.ParentIdx += base
+			}
+			if err := p.allocToken(tok); err != nil {
+				return nil, err
+			}
 		}
-		pos++
 	}
 	return p.Tokens(), nil
 }
 
-// ParseStreamDecoder uses json.Decoder for incremental tokenizing during I/O.
+// ParseStreamDecoder tokenizes JSON from an io.Reader with a Decoder, so the
+// input is validated while it is read and token offsets are exact positions
+// in the stream.
 func ParseStreamDecoder(r io.Reader, numTokens int) ([]Token, error) {
-	dec := json.NewDecoder(r)
+	dec := NewDecoder(r)
+	dec.UseNumber() // Skip float conversion; only spans are kept.
 	p := NewParser(numTokens)
+	p.toksuper = -1
 	for {
-		tok, err := dec.Token()
+		tok, start, end, err := dec.nextToken()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
 			return nil, fmt.Errorf("decoder error: %w", err)
 		}
-		// Map json.Token to our Token (basic; expand for full support).
-		ourTok := Token{ParentIdx: p.toksuper}
+		ourTok := Token{Start: int(start), End: int(end), ParentIdx: p.toksuper}
 		switch v := tok.(type) {
 		case json.Delim:
 			switch v {
@@ -300,22 +562,23 @@ func ParseStreamDecoder(r io.Reader, numTokens int) ([]Token, error) {
 			case '[':
 				ourTok.Type = Array
 			case '}', ']':
-				if p.toksuper != -1 {
-					p.tokens[p.toksuper].End = p.pos + 1 // Approximate positions.
-					p.toksuper = p.tokens[p.toksuper].ParentIdx
-				}
+				p.tokens[p.toksuper].End = int(end)
+				p.toksuper = p.tokens[p.toksuper].ParentIdx
 				continue // Delims like }/] don't need new tokens.
 			}
 		case string:
 			ourTok.Type = String
+			ourTok.Start++ // Exclude the quotes, as Parse does.
+			ourTok.End--
 		default: // Numbers, booleans, null.
 			ourTok.Type = Primitive
 		}
 		if err := p.allocToken(ourTok); err != nil {
 			return nil, err
 		}
-		p.toksuper = p.toknext - 1 // Update for containers.
-		p.pos++                    // Increment position (stub; track real offsets from dec).
+		if ourTok.Type == Object || ourTok.Type == Array {
+			p.toksuper = p.toknext - 1
+		}
 	}
 	return p.Tokens(), nil
 }
@@ -0,0 +1,520 @@
+package jsmngo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Token states, mirroring encoding/json's Decoder.
+const (
+	tokenTopValue = iota
+	tokenArrayStart
+	tokenArrayValue
+	tokenArrayComma
+	tokenObjectStart
+	tokenObjectKey
+	tokenObjectColon
+	tokenObjectValue
+	tokenObjectComma
+)
+
+const minRead = 4096
+
+// SyntaxError describes malformed JSON found by a Decoder.
+type SyntaxError struct {
+	Msg    string
+	Offset int64 // Input offset at which the error was found.
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Msg, e.Offset)
+}
+
+// Decoder reads JSON values from an input stream. It has the same method set
+// as encoding/json's Decoder and returns the same json.Token values. Tokens
+// are read with a resumable scanner of its own that finds value boundaries
+// as input arrives and validates strings, numbers and literals as
+// encoding/json does; encoding/json is only used to bind values in Decode.
+type Decoder struct {
+	r     io.Reader
+	buf   []byte
+	scanp int   // Start of unread data in buf.
+	base  int64 // Input offset of buf[0].
+	err   error // Sticky read error.
+
+	tokenState int
+	tokenStack []int
+
+	useNumber             bool
+	disallowUnknownFields bool
+}
+
+// NewDecoder returns a Decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// UseNumber makes Token and Decode return numbers as json.Number instead of float64.
+func (d *Decoder) UseNumber() {
+	d.useNumber = true
+}
+
+// DisallowUnknownFields makes Decode reject object keys that do not match a
+// field of the destination struct.
+func (d *Decoder) DisallowUnknownFields() {
+	d.disallowUnknownFields = true
+}
+
+// Buffered returns the data read from the underlying reader but not yet consumed.
+func (d *Decoder) Buffered() io.Reader {
+	return bytes.NewReader(d.buf[d.scanp:])
+}
+
+// InputOffset returns the offset just past the last consumed token.
+func (d *Decoder) InputOffset() int64 {
+	return d.base + int64(d.scanp)
+}
+
+// More reports whether there is another element in the current array or
+// object, or another top-level value.
+func (d *Decoder) More() bool {
+	c, err := d.peek()
+	return err == nil && c != ']' && c != '}'
+}
+
+// Decode reads the next JSON value and stores it in v, following the rules
+// of json.Unmarshal. It may be interleaved with Token calls.
+func (d *Decoder) Decode(v any) error {
+	raw, start, err := d.readValue()
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if d.useNumber {
+		dec.UseNumber()
+	}
+	if d.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("decode value at offset %d: %w", start, err)
+	}
+	return nil
+}
+
+// readValue consumes the next value wherever Decode would, returning its raw
+// bytes and input offset. Literals and numbers are checked, since their
+// extent runs to the next delimiter and may hold trailing junk that
+// json.Decoder would stop before; the contents of strings, arrays and objects
+// are left to Decode. The bytes are valid until the next read.
+func (d *Decoder) readValue() ([]byte, int64, error) {
+	if err := d.tokenPrepareForDecode(); err != nil {
+		return nil, 0, err
+	}
+	c, err := d.peek()
+	if err != nil {
+		return nil, 0, err
+	}
+	if !d.tokenValueAllowed() {
+		return nil, 0, d.tokenError(c)
+	}
+	start := d.InputOffset()
+	n, err := d.scanValue()
+	if err != nil {
+		return nil, 0, err
+	}
+	raw := d.buf[d.scanp : d.scanp+n]
+	if msg := scalarSyntax(raw); msg != "" {
+		return nil, 0, &SyntaxError{Msg: msg, Offset: start}
+	}
+	d.scanp += n
+	d.tokenValueEnd()
+	return raw, start, nil
+}
+
+// Token returns the next JSON token in the input stream: json.Delim for
+// brackets and braces, bool, float64 (or json.Number), string, or nil. Commas
+// and colons are consumed and checked but not returned. At the end of the
+// input Token returns nil, io.EOF.
+func (d *Decoder) Token() (json.Token, error) {
+	tok, _, _, err := d.nextToken()
+	return tok, err
+}
+
+// nextToken is Token that also reports the input span of the token. String
+// spans include their quotes.
+func (d *Decoder) nextToken() (json.Token, int64, int64, error) {
+	for {
+		c, err := d.peek()
+		if err != nil {
+			if err == io.EOF && len(d.tokenStack) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, 0, 0, err
+		}
+		start := d.InputOffset()
+		switch c {
+		case '[', '{':
+			if !d.tokenValueAllowed() {
+				return nil, 0, 0, d.tokenError(c)
+			}
+			d.scanp++
+			d.tokenStack = append(d.tokenStack, d.tokenState)
+			d.tokenState = tokenArrayStart
+			if c == '{' {
+				d.tokenState = tokenObjectStart
+			}
+			return json.Delim(c), start, start + 1, nil
+		case ']', '}':
+			if c == ']' && d.tokenState != tokenArrayStart && d.tokenState != tokenArrayComma ||
+				c == '}' && d.tokenState != tokenObjectStart && d.tokenState != tokenObjectComma {
+				return nil, 0, 0, d.tokenError(c)
+			}
+			d.scanp++
+			d.tokenState = d.tokenStack[len(d.tokenStack)-1]
+			d.tokenStack = d.tokenStack[:len(d.tokenStack)-1]
+			d.tokenValueEnd()
+			return json.Delim(c), start, start + 1, nil
+		case ':':
+			if d.tokenState != tokenObjectColon {
+				return nil, 0, 0, d.tokenError(c)
+			}
+			d.scanp++
+			d.tokenState = tokenObjectValue
+			continue
+		case ',':
+			switch d.tokenState {
+			case tokenArrayComma:
+				d.tokenState = tokenArrayValue
+			case tokenObjectComma:
+				d.tokenState = tokenObjectKey
+			default:
+				return nil, 0, 0, d.tokenError(c)
+			}
+			d.scanp++
+			continue
+		case '"':
+			if d.tokenState == tokenObjectStart || d.tokenState == tokenObjectKey {
+				v, n, err := d.readScalar()
+				if err != nil {
+					return nil, 0, 0, err
+				}
+				d.tokenState = tokenObjectColon
+				return v, start, start + int64(n), nil
+			}
+		}
+		if !d.tokenValueAllowed() {
+			return nil, 0, 0, d.tokenError(c)
+		}
+		v, n, err := d.readScalar()
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		d.tokenValueEnd()
+		return v, start, start + int64(n), nil
+	}
+}
+
+func (d *Decoder) tokenValueAllowed() bool {
+	switch d.tokenState {
+	case tokenTopValue, tokenArrayStart, tokenArrayValue, tokenObjectValue:
+		return true
+	}
+	return false
+}
+
+func (d *Decoder) tokenValueEnd() {
+	switch d.tokenState {
+	case tokenArrayStart, tokenArrayValue:
+		d.tokenState = tokenArrayComma
+	case tokenObjectValue:
+		d.tokenState = tokenObjectComma
+	}
+}
+
+// tokenPrepareForDecode consumes the separator Token would have skipped
+// before a value.
+func (d *Decoder) tokenPrepareForDecode() error {
+	sep, next := byte(','), tokenArrayValue
+	switch d.tokenState {
+	case tokenArrayComma:
+	case tokenObjectColon:
+		sep, next = ':', tokenObjectValue
+	default:
+		return nil
+	}
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if c != sep {
+		return d.tokenError(c)
+	}
+	d.scanp++
+	d.tokenState = next
+	return nil
+}
+
+func (d *Decoder) tokenError(c byte) error {
+	var context string
+	switch d.tokenState {
+	case tokenArrayComma:
+		context = "after array element"
+	case tokenObjectStart, tokenObjectKey:
+		context = "looking for beginning of object key string"
+	case tokenObjectColon:
+		context = "after object key"
+	case tokenObjectComma:
+		context = "after object key:value pair"
+	default:
+		context = "looking for beginning of value"
+	}
+	return &SyntaxError{Msg: fmt.Sprintf("invalid character %q %s", c, context), Offset: d.InputOffset()}
+}
+
+// readScalar consumes the string, number or literal at the read position.
+func (d *Decoder) readScalar() (json.Token, int, error) {
+	n, err := d.scanValue()
+	if err != nil {
+		return nil, 0, err
+	}
+	raw := d.buf[d.scanp : d.scanp+n]
+	if msg := scalarSyntax(raw); msg != "" {
+		return nil, 0, &SyntaxError{Msg: msg, Offset: d.InputOffset()}
+	}
+	var v json.Token
+	switch raw[0] {
+	case '"':
+		for i, c := range raw[1 : n-1] {
+			if c < ' ' {
+				return nil, 0, &SyntaxError{Msg: fmt.Sprintf("invalid character %q in string literal", c), Offset: d.InputOffset() + int64(i) + 1}
+			}
+		}
+		s, err := unescape(raw[1 : n-1])
+		if err != nil {
+			return nil, 0, &SyntaxError{Msg: err.Error(), Offset: d.InputOffset()}
+		}
+		v = validUTF8(s)
+	case 't':
+		v = true
+	case 'f':
+		v = false
+	case 'n':
+		v = nil
+	default:
+		if d.useNumber {
+			v = json.Number(raw)
+			break
+		}
+		f, err := strconv.ParseFloat(string(raw), 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("number %s at offset %d: %w", raw, d.InputOffset(), err)
+		}
+		v = f
+	}
+	d.scanp += n
+	return v, n, nil
+}
+
+// scalarSyntax describes what is wrong with the literal or number raw, whose
+// extent scanValue has found, or returns "" if it is valid. Strings, arrays
+// and objects are not checked.
+func scalarSyntax(raw []byte) string {
+	switch raw[0] {
+	case '"', '[', '{':
+	case 't', 'f', 'n':
+		if s := string(raw); s != "true" && s != "false" && s != "null" {
+			return fmt.Sprintf("invalid literal %q", raw)
+		}
+	default:
+		if !isValidNumber(raw) {
+			return fmt.Sprintf("invalid number %q", raw)
+		}
+	}
+	return ""
+}
+
+// validUTF8 converts s to a string, replacing each byte that is not part of
+// valid UTF-8 with U+FFFD as encoding/json does.
+func validUTF8(s []byte) string {
+	if utf8.Valid(s) {
+		return string(s)
+	}
+	out := make([]byte, 0, len(s)+8)
+	for len(s) > 0 {
+		r, size := utf8.DecodeRune(s)
+		out = utf8.AppendRune(out, r)
+		s = s[size:]
+	}
+	return string(out)
+}
+
+// peek skips whitespace and returns the next byte without consuming it.
+func (d *Decoder) peek() (byte, error) {
+	for {
+		for i := d.scanp; i < len(d.buf); i++ {
+			switch d.buf[i] {
+			case ' ', '\t', '\r', '\n':
+				continue
+			}
+			d.scanp = i
+			return d.buf[i], nil
+		}
+		d.scanp = len(d.buf)
+		if err := d.refill(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// scanValue returns the length of the value starting at the read position,
+// reading more input as needed. It does not consume the value.
+func (d *Decoder) scanValue() (int, error) {
+	var s valueScanner
+	n := 0
+	for {
+		m, done := s.feed(d.buf[d.scanp+n:])
+		n += m
+		if done {
+			return n, nil
+		}
+		if err := d.refill(); err != nil {
+			if err == io.EOF {
+				if s.primitive && n > 0 {
+					return n, nil
+				}
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+	}
+}
+
+// refill reads more input, discarding consumed bytes first.
+func (d *Decoder) refill() error {
+	if d.err != nil {
+		return d.err
+	}
+	if d.scanp > 0 {
+		d.base += int64(d.scanp)
+		n := copy(d.buf, d.buf[d.scanp:])
+		d.buf = d.buf[:n]
+		d.scanp = 0
+	}
+	if cap(d.buf)-len(d.buf) < minRead {
+		buf := make([]byte, len(d.buf), 2*cap(d.buf)+minRead)
+		copy(buf, d.buf)
+		d.buf = buf
+	}
+	n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+	d.buf = d.buf[:len(d.buf)+n]
+	if err != nil {
+		d.err = err
+		if n == 0 {
+			return err
+		}
+	}
+	return nil
+}
+
+// valueScanner finds the end of one JSON value. It keeps its state between
+// calls to feed, so a value may be split across buffers.
+type valueScanner struct {
+	depth     int
+	inString  bool
+	escape    bool
+	primitive bool // Scanning a bare number or literal.
+}
+
+// feed consumes b and returns how many bytes belong to the value and whether
+// the value ended within b. A primitive only ends at a delimiter, so at the
+// end of input the caller must treat a pending primitive as complete.
+func (s *valueScanner) feed(b []byte) (int, bool) {
+	for i, c := range b {
+		switch {
+		case s.inString:
+			switch {
+			case s.escape:
+				s.escape = false
+			case c == '\\':
+				s.escape = true
+			case c == '"':
+				s.inString = false
+				if s.depth == 0 {
+					return i + 1, true
+				}
+			}
+		case s.primitive:
+			switch c {
+			case ' ', '\t', '\r', '\n', ',', ':', ']', '}', '[', '{', '"':
+				return i, true
+			}
+		default:
+			switch c {
+			case '"':
+				s.inString = true
+			case '{', '[':
+				s.depth++
+			case '}', ']':
+				s.depth--
+				if s.depth <= 0 {
+					return i + 1, true
+				}
+			case ' ', '\t', '\r', '\n', ',', ':':
+			default:
+				if s.depth == 0 {
+					s.primitive = true
+				}
+			}
+		}
+	}
+	return len(b), false
+}
+
+// isValidNumber reports whether b matches the RFC 8259 number grammar.
+func isValidNumber(b []byte) bool {
+	i := 0
+	if i < len(b) && b[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(b) && b[i] == '0':
+		i++
+	case i < len(b) && b[i] >= '1' && b[i] <= '9':
+		for i < len(b) && isDigit(b[i]) {
+			i++
+		}
+	default:
+		return false
+	}
+	if i < len(b) && b[i] == '.' {
+		i++
+		if i == len(b) || !isDigit(b[i]) {
+			return false
+		}
+		for i < len(b) && isDigit(b[i]) {
+			i++
+		}
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			i++
+		}
+		if i == len(b) || !isDigit(b[i]) {
+			return false
+		}
+		for i < len(b) && isDigit(b[i]) {
+			i++
+		}
+	}
+	return i == len(b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
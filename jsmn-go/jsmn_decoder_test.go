@@ -0,0 +1,164 @@
+package jsmngo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var decoderInputs = []string{
+	`{"key": "value", "arr": [1, 2.5, -3e2], "t": true, "f": false, "n": null}`,
+	`[[], {}, [{"a": [1]}], "esc\"apedé😀"]`,
+	`1 "two" [3] {"four": 4}`,
+	"  \n\t{ \"spaced\" :\r\n [ 1 , 2 ] }  ",
+	"[\"bad\xffutf8\", {\"\xc3\": \"\xe2\x82\\u00e9\xed\xa0\x80\"}]", // Invalid UTF-8 becomes U+FFFD.
+}
+
+func TestDecoderTokenMatchesEncodingJSON(t *testing.T) {
+	for _, in := range decoderInputs {
+		want := json.NewDecoder(strings.NewReader(in))
+		got := NewDecoder(iotest.OneByteReader(strings.NewReader(in)))
+		for {
+			wt, werr := want.Token()
+			gt, gerr := got.Token()
+			if (werr == nil) != (gerr == nil) {
+				t.Fatalf("%s: error mismatch: want %v, got %v", in, werr, gerr)
+			}
+			if werr != nil {
+				break
+			}
+			if !reflect.DeepEqual(wt, gt) {
+				t.Fatalf("%s: token mismatch: want %#v, got %#v", in, wt, gt)
+			}
+			if want.InputOffset() != got.InputOffset() {
+				t.Fatalf("%s: offset mismatch after %v: want %d, got %d", in, wt, want.InputOffset(), got.InputOffset())
+			}
+			if want.More() != got.More() {
+				t.Fatalf("%s: More mismatch after %v", in, wt)
+			}
+		}
+	}
+}
+
+func TestDecoderDecodeWithTokens(t *testing.T) {
+	in := `{"items": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}], "big": 12345678901234567890}`
+	dec := NewDecoder(strings.NewReader(in))
+	dec.UseNumber()
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	var items []item
+	expect := func(want json.Token) {
+		t.Helper()
+		tok, err := dec.Token()
+		if err != nil || tok != want {
+			t.Fatalf("expected %v, got %v (%v)", want, tok, err)
+		}
+	}
+	expect(json.Delim('{'))
+	expect("items")
+	expect(json.Delim('['))
+	for dec.More() {
+		var it item
+		if err := dec.Decode(&it); err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, it)
+	}
+	expect(json.Delim(']'))
+	expect("big")
+	var big json.Number
+	if err := dec.Decode(&big); err != nil {
+		t.Fatal(err)
+	}
+	expect(json.Delim('}'))
+	if _, err := dec.Token(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if len(items) != 2 || items[1].Name != "b" || big.String() != "12345678901234567890" {
+		t.Errorf("unexpected decode results: %+v %v", items, big)
+	}
+}
+
+func TestDecoderDecodeStream(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("{\"a\": 1}\n{\"a\": 2}\n"))
+	dec.DisallowUnknownFields()
+	var sum int
+	for {
+		var v struct{ A int }
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += v.A
+	}
+	if sum != 3 {
+		t.Errorf("expected sum 3, got %d", sum)
+	}
+	dec = NewDecoder(strings.NewReader(`{"b": 1}`))
+	dec.DisallowUnknownFields()
+	var v struct{ A int }
+	if err := dec.Decode(&v); err == nil {
+		t.Error("expected unknown field error")
+	}
+}
+
+func TestDecoderSyntaxErrors(t *testing.T) {
+	for _, in := range []string{`{"a" 1}`, `[1 2]`, `{1: 2}`, `[1,]`, `tru`, `01`, `{"a": 1]`, "\"\x01\"", "[\"tab\there\"]", "{\"new\nline\": 1}"} {
+		dec := NewDecoder(strings.NewReader(in))
+		var err error
+		for err == nil {
+			_, err = dec.Token()
+		}
+		var syn *SyntaxError
+		if !errors.As(err, &syn) {
+			t.Errorf("%s: expected SyntaxError, got %v", in, err)
+		}
+	}
+	for _, in := range []string{`123abc`, `truex`, `1.5.5`, `[1, 2x]`, `{"a": nul}`} {
+		dec := NewDecoder(strings.NewReader(in))
+		if in[0] == '[' || in[0] == '{' {
+			dec.Token()
+			dec.Token()
+		}
+		var v any
+		err := dec.Decode(&v)
+		var syn *SyntaxError
+		if !errors.As(err, &syn) {
+			t.Errorf("%s: expected Decode to return a SyntaxError, got %v (value %v)", in, err, v)
+		}
+	}
+	dec := NewDecoder(strings.NewReader(`[1, 2`))
+	var err error
+	for err == nil {
+		_, err = dec.Token()
+	}
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestParseStreamDecoderOffsets(t *testing.T) {
+	for _, in := range decoderInputs[:2] {
+		p := NewParser(64)
+		if _, err := p.Parse([]byte(in)); err != nil {
+			t.Fatal(err)
+		}
+		tokens, err := ParseStreamDecoder(iotest.HalfReader(bytes.NewReader([]byte(in))), 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tokens, p.Tokens()) {
+			t.Errorf("%s: tokens differ from Parse:\n%v\n%v", in, tokens, p.Tokens())
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package jsmngo
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// dupKeyLinearMax is the number of keys an object may hold before duplicate
+// lookups switch from a linear scan to a map.
+const dupKeyLinearMax = 16
+
+// DuplicateKeyError reports a key that appears more than once in one object.
+type DuplicateKeyError struct {
+	Key    string // Unescaped key value.
+	Path   string // JSON Pointer of the duplicate member.
+	First  int    // Start offset of the first occurrence of the key.
+	Offset int    // Start offset of the duplicate occurrence.
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("duplicate key %q at offset %d (first at %d), path %s", e.Key, e.Offset, e.First, e.Path)
+}
+
+type dupKey struct {
+	name []byte
+	tok  int
+}
+
+// keyFrame tracks the keys seen so far in one open container.
+type keyFrame struct {
+	idx   int // Container token index.
+	count int // Children seen so far.
+	keys  []dupKey
+	index map[string]int // Used once keys exceeds dupKeyLinearMax.
+}
+
+// CheckDuplicateKeys reports the first object in tokens that contains the same
+// key twice. Keys are compared after unescaping, so "a" and "\u0061" collide.
+// tokens must come from tokenizing json.
+func CheckDuplicateKeys(json []byte, tokens []Token) error {
+	var stack []keyFrame
+	depth := 0
+	for i := range tokens {
+		tok := &tokens[i]
+		for depth > 0 && stack[depth-1].idx != tok.ParentIdx {
+			depth--
+		}
+		if depth > 0 {
+			f := &stack[depth-1]
+			f.count++
+			if tokens[f.idx].Type == Object && f.count%2 == 1 && tok.Type == String {
+				if err := f.add(json, tokens, i); err != nil {
+					return err
+				}
+			}
+		}
+		if tok.Type == Object || tok.Type == Array {
+			if depth == len(stack) {
+				stack = append(stack, keyFrame{})
+			}
+			f := &stack[depth]
+			f.idx, f.count, f.keys = i, 0, f.keys[:0]
+			if f.index != nil {
+				clear(f.index)
+			}
+			depth++
+		}
+	}
+	return nil
+}
+
+func (f *keyFrame) add(json []byte, tokens []Token, i int) error {
+	tok := tokens[i]
+	name, err := unescape(json[tok.Start:tok.End])
+	if err != nil {
+		return fmt.Errorf("key at offset %d: %w", tok.Start, err)
+	}
+	first := -1
+	if len(f.keys) >= dupKeyLinearMax {
+		if f.index == nil {
+			f.index = make(map[string]int, 2*dupKeyLinearMax)
+		}
+		if len(f.index) == 0 {
+			for _, k := range f.keys {
+				f.index[string(k.name)] = k.tok
+			}
+		}
+		if j, ok := f.index[string(name)]; ok {
+			first = j
+		} else {
+			f.index[string(name)] = i
+		}
+	} else {
+		for _, k := range f.keys {
+			if bytes.Equal(k.name, name) {
+				first = k.tok
+				break
+			}
+		}
+	}
+	f.keys = append(f.keys, dupKey{name: name, tok: i})
+	if first >= 0 {
+		return &DuplicateKeyError{
+			Key:    string(name),
+			Path:   pointer(json, tokens, i),
+			First:  tokens[first].Start,
+			Offset: tok.Start,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package jsmngo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDisallowDuplicateKeys(t *testing.T) {
+	cases := []struct {
+		json string
+		path string
+	}{
+		{`{"a": 1, "b": 2}`, ""},
+		{`{"a": 1, "a": 2}`, "/a"},
+		{`{"a": 1, "\u0061": 2}`, "/a"},
+		{`{"x": [{"k": 1}, {"k": 1, "k": 2}]}`, "/x/1/k"},
+		{`{"a": {"b": 1}, "c": {"b": 1}}`, ""},
+		{`{"a/b": {"~": 1, "~": 2}}`, "/a~1b/~0"},
+	}
+	for _, c := range cases {
+		p := NewParser(32)
+		p.DisallowDuplicateKeys()
+		_, err := p.Parse([]byte(c.json))
+		if c.path == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", c.json, err)
+			}
+			continue
+		}
+		var dup *DuplicateKeyError
+		if !errors.As(err, &dup) {
+			t.Errorf("%s: expected DuplicateKeyError, got %v", c.json, err)
+			continue
+		}
+		if dup.Path != c.path {
+			t.Errorf("%s: expected path %s, got %s", c.json, c.path, dup.Path)
+		}
+	}
+}
+
+func TestDuplicateKeysDefaultAllowed(t *testing.T) {
+	p := NewParser(10)
+	if _, err := p.Parse([]byte(`{"a": 1, "a": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckDuplicateKeysLargeObject(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("{")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, `"k%d": %d, `, i, i)
+	}
+	b.WriteString(`"k42": 0}`)
+	json := []byte(b.String())
+	p := NewParser(256)
+	if _, err := p.Parse(json); err != nil {
+		t.Fatal(err)
+	}
+	err := CheckDuplicateKeys(json, p.Tokens())
+	var dup *DuplicateKeyError
+	if !errors.As(err, &dup) {
+		t.Fatalf("expected DuplicateKeyError, got %v", err)
+	}
+	if dup.Key != "k42" || json[dup.First-1] != '"' || dup.Offset <= dup.First {
+		t.Errorf("unexpected error fields: %+v", dup)
+	}
+}
@@ -0,0 +1,47 @@
+package jsmngo
+
+import "fmt"
+
+// Raw returns the exact input bytes of token i and its subtree: the whole
+// object or array including brackets, or a string including its quotes. The
+// result aliases json.
+func Raw(json []byte, tokens []Token, i int) ([]byte, error) {
+	if i < 0 || i >= len(tokens) {
+		return nil, fmt.Errorf("token index %d out of range", i)
+	}
+	start, end := rawSpan(tokens[i])
+	if start < 0 || end > len(json) || start > end {
+		return nil, fmt.Errorf("token %d span %d-%d outside input", i, start, end)
+	}
+	return json[start:end], nil
+}
+
+// rawSpan widens string tokens to include their quotes.
+func rawSpan(tok Token) (int, int) {
+	if tok.Type == String {
+		return tok.Start - 1, tok.End + 1
+	}
+	return tok.Start, tok.End
+}
+
+// Extract returns the raw bytes of token i's subtree together with a
+// standalone token set for them, as if the bytes had been passed to Parse.
+// The tokens are derived from the existing ones by rebasing offsets and
+// parent indices, so nothing is scanned again.
+func Extract(json []byte, tokens []Token, i int) ([]byte, []Token, error) {
+	raw, err := Raw(json, tokens, i)
+	if err != nil {
+		return nil, nil, err
+	}
+	base, _ := rawSpan(tokens[i])
+	end := skipToken(tokens, i)
+	sub := make([]Token, end-i)
+	copy(sub, tokens[i:end])
+	for j := range sub {
+		sub[j].Start -= base
+		sub[j].End -= base
+		sub[j].ParentIdx -= i
+	}
+	sub[0].ParentIdx = -1
+	return raw, sub, nil
+}
@@ -0,0 +1,42 @@
+package jsmngo
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	json := []byte(`{"a": 1, "b": {"c": [true, "x\"y"], "d": null}, "e": "str"}`)
+	p := NewParser(32)
+	if _, err := p.Parse(json); err != nil {
+		t.Fatal(err)
+	}
+	tokens := p.Tokens()
+	cases := map[int]string{
+		0:  string(json),
+		3:  `"b"`,
+		4:  `{"c": [true, "x\"y"], "d": null}`,
+		6:  `[true, "x\"y"]`,
+		8:  `"x\"y"`,
+		10: `null`,
+	}
+	for i, want := range cases {
+		raw, sub, err := Extract(json, tokens, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != want {
+			t.Errorf("token %d: expected %s, got %s", i, want, raw)
+		}
+		reparsed := NewParser(32)
+		if _, err := reparsed.Parse(raw); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(sub, reparsed.Tokens()) {
+			t.Errorf("token %d: rebased tokens differ from Parse:\n%v\n%v", i, sub, reparsed.Tokens())
+		}
+	}
+	if _, err := Raw(json, tokens, len(tokens)); err == nil {
+		t.Error("expected error for out of range index")
+	}
+}
@@ -0,0 +1,128 @@
+package jsmngo
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"unicode/utf8"
+)
+
+// ColumnUnit selects how columns are counted within a line.
+type ColumnUnit int
+
+const (
+	// UTF8Column counts bytes (UTF-8 code units) from the start of the line.
+	UTF8Column ColumnUnit = iota
+	// UTF16Column counts UTF-16 code units, as used by LSP by default.
+	UTF16Column
+)
+
+// Position is a zero-based line and column in the input.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Range is a half-open span between two positions.
+type Range struct {
+	Start Position
+	End   Position
+}
+
+// LineIndex maps byte offsets to line and column positions and back. Lines
+// are terminated by '\n'; a preceding '\r' belongs to the line it ends.
+type LineIndex struct {
+	src    []byte
+	starts []int // Offset of the first byte of each line.
+}
+
+// NewLineIndex scans json for line starts. Prefer Parser.TrackLines when the
+// input is being tokenized anyway.
+func NewLineIndex(json []byte) *LineIndex {
+	starts := []int{0}
+	for off := 0; ; {
+		i := bytes.IndexByte(json[off:], '\n')
+		if i < 0 {
+			break
+		}
+		off += i + 1
+		starts = append(starts, off)
+	}
+	return &LineIndex{src: json, starts: starts}
+}
+
+// LineCount returns the number of lines in the input.
+func (li *LineIndex) LineCount() int {
+	return len(li.starts)
+}
+
+// Position returns the position of offset, clamped to the input.
+func (li *LineIndex) Position(offset int, unit ColumnUnit) Position {
+	offset = max(0, min(offset, len(li.src)))
+	line, found := slices.BinarySearch(li.starts, offset)
+	if !found {
+		line--
+	}
+	start := li.starts[line]
+	col := offset - start
+	if unit == UTF16Column {
+		col = utf16Len(li.src[start:offset])
+	}
+	return Position{Line: line, Column: col}
+}
+
+// TokenRange returns the range covered by tok. String tokens exclude their
+// quotes, matching Token.Start and Token.End.
+func (li *LineIndex) TokenRange(tok Token, unit ColumnUnit) Range {
+	return Range{Start: li.Position(tok.Start, unit), End: li.Position(tok.End, unit)}
+}
+
+// Offset returns the byte offset of pos. A column may point at the end of its
+// line but not past it, and a UTF-16 column may not split a surrogate pair.
+func (li *LineIndex) Offset(pos Position, unit ColumnUnit) (int, error) {
+	if pos.Line < 0 || pos.Line >= len(li.starts) || pos.Column < 0 {
+		return 0, errors.New("position out of range")
+	}
+	start := li.starts[pos.Line]
+	end := len(li.src)
+	if pos.Line+1 < len(li.starts) {
+		end = li.starts[pos.Line+1] - 1 // Exclude the '\n'.
+	}
+	if unit == UTF8Column {
+		if start+pos.Column > end {
+			return 0, errors.New("column past end of line")
+		}
+		return start + pos.Column, nil
+	}
+	off, col := start, 0
+	for col < pos.Column {
+		if off >= end {
+			return 0, errors.New("column past end of line")
+		}
+		r, size := utf8.DecodeRune(li.src[off:end])
+		off += size
+		col++
+		if r >= 0x10000 {
+			col++
+		}
+	}
+	if col != pos.Column {
+		return 0, errors.New("column splits a surrogate pair")
+	}
+	return off, nil
+}
+
+// utf16Len returns the number of UTF-16 code units needed to encode b.
+// Invalid bytes count as one unit each, like the replacement character.
+func utf16Len(b []byte) int {
+	n := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		n++
+		if r >= 0x10000 {
+			n++
+		}
+	}
+	return n
+}
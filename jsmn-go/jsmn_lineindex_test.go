@@ -0,0 +1,58 @@
+package jsmngo
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParserLineIndex(t *testing.T) {
+	json := []byte("{\n  \"k\": \"é😀\",\r\n  \"n\": 1\n}")
+	p := NewParser(10)
+	p.TrackLines()
+	if _, err := p.Parse(json); err != nil {
+		t.Fatal(err)
+	}
+	li := p.LineIndex()
+	if li.LineCount() != 4 {
+		t.Fatalf("expected 4 lines, got %d", li.LineCount())
+	}
+	if got := NewLineIndex(json); !slices.Equal(got.starts, li.starts) {
+		t.Errorf("NewLineIndex starts %v, parser starts %v", got.starts, li.starts)
+	}
+	tokens := p.Tokens()
+	val := tokens[2] // "é😀"
+	r8 := li.TokenRange(val, UTF8Column)
+	r16 := li.TokenRange(val, UTF16Column)
+	if r8 != (Range{Position{1, 8}, Position{1, 14}}) {
+		t.Errorf("unexpected UTF-8 range %+v", r8)
+	}
+	if r16 != (Range{Position{1, 8}, Position{1, 11}}) {
+		t.Errorf("unexpected UTF-16 range %+v", r16)
+	}
+	num := li.Position(tokens[4].Start, UTF16Column)
+	if num != (Position{2, 7}) {
+		t.Errorf("unexpected position %+v", num)
+	}
+}
+
+func TestLineIndexOffset(t *testing.T) {
+	json := []byte("[\"😀x\",\n1]")
+	li := NewLineIndex(json)
+	for off := 0; off <= len(json); off++ {
+		if off > 2 && off < 6 {
+			continue // Inside the emoji.
+		}
+		for _, unit := range []ColumnUnit{UTF8Column, UTF16Column} {
+			got, err := li.Offset(li.Position(off, unit), unit)
+			if err != nil || got != off {
+				t.Errorf("offset %d unit %d: round trip got %d, %v", off, unit, got, err)
+			}
+		}
+	}
+	if _, err := li.Offset(Position{0, 3}, UTF16Column); err == nil {
+		t.Error("expected error for column inside surrogate pair")
+	}
+	if _, err := li.Offset(Position{1, 3}, UTF8Column); err == nil {
+		t.Error("expected error for column past end of line")
+	}
+}
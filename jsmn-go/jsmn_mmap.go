@@ -0,0 +1,85 @@
+package jsmngo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// MappedFile is a JSON file tokenized in place. On Linux the contents are
+// memory-mapped read-only, so tokens can slice Bytes without copying the
+// file into the heap. Bytes and any slices taken from it must not be used
+// after Close.
+type MappedFile struct {
+	data   []byte
+	tokens []Token
+	mapped bool // data must be unmapped on Close.
+}
+
+// ParseFile maps the file at path and tokenizes it with a Parser sized for
+// numTokens.
+func ParseFile(path string, numTokens int) (*MappedFile, error) {
+	return parseFile(path, func(json []byte) ([]Token, error) {
+		p := NewParser(numTokens)
+		if _, err := p.Parse(json); err != nil {
+			return nil, err
+		}
+		return p.Tokens(), nil
+	})
+}
+
+// ParseFileParallel maps the file at path and tokenizes it with ParseParallel.
+func ParseFileParallel(path string, numTokens int) (*MappedFile, error) {
+	return parseFile(path, func(json []byte) ([]Token, error) {
+		return ParseParallel(json, numTokens)
+	})
+}
+
+func parseFile(path string, parse func([]byte) ([]Token, error)) (*MappedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open json file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat json file: %w", err)
+	}
+	size := info.Size()
+	if size != int64(int(size)) {
+		return nil, errors.New("json file too large to map")
+	}
+	mf := &MappedFile{}
+	if size > 0 {
+		mf.data, mf.mapped, err = mapFile(f, int(size))
+		if err != nil {
+			return nil, err
+		}
+	}
+	mf.tokens, err = parse(mf.data)
+	if err != nil {
+		_ = mf.Close()
+		return nil, err
+	}
+	return mf, nil
+}
+
+// Bytes returns the file contents. Token offsets index into this slice.
+func (mf *MappedFile) Bytes() []byte {
+	return mf.data
+}
+
+// Tokens returns the parsed tokens.
+func (mf *MappedFile) Tokens() []Token {
+	return mf.tokens
+}
+
+// Close releases the mapping. It is safe to call more than once.
+func (mf *MappedFile) Close() error {
+	data, mapped := mf.data, mf.mapped
+	mf.data, mf.tokens, mf.mapped = nil, nil, false
+	if !mapped {
+		return nil
+	}
+	return unmapFile(data)
+}
@@ -0,0 +1,25 @@
+//go:build linux
+
+package jsmngo
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func mapFile(f *os.File, size int) ([]byte, bool, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, false, fmt.Errorf("mmap json file: %w", err)
+	}
+	_ = syscall.Madvise(data, syscall.MADV_SEQUENTIAL) // Only a hint.
+	return data, true, nil
+}
+
+func unmapFile(data []byte) error {
+	if err := syscall.Munmap(data); err != nil {
+		return fmt.Errorf("munmap json file: %w", err)
+	}
+	return nil
+}
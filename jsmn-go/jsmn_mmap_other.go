@@ -0,0 +1,22 @@
+//go:build !linux
+
+package jsmngo
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// mapFile falls back to reading the file into memory where mmap is not wired up.
+func mapFile(f *os.File, size int) ([]byte, bool, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, false, fmt.Errorf("read json file: %w", err)
+	}
+	return data, false, nil
+}
+
+func unmapFile([]byte) error {
+	return nil
+}
@@ -0,0 +1,106 @@
+package jsmngo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte(`{"key": "value", "arr": [1, 2, 3]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mf, err := ParseFile(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := mf.Tokens()
+	if len(tokens) != 8 {
+		t.Fatalf("expected 8 tokens, got %d", len(tokens))
+	}
+	if got := string(mf.Bytes()[tokens[2].Start:tokens[2].End]); got != "value" {
+		t.Errorf("expected value, got %q", got)
+	}
+	if err := mf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mf.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if mf.Bytes() != nil {
+		t.Error("expected nil Bytes after Close")
+	}
+}
+
+func TestParseFileParallel(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := range 3000 {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "name": "a,b]\\\"%d", "tags": [1, {"x": null}]}`, i, i)
+	}
+	b.WriteString("]\n")
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mf, err := ParseFileParallel(path, 50000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mf.Close()
+
+	p := NewParser(50000)
+	if _, err := p.Parse(mf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	want := p.Tokens()
+	if !slices.Equal(mf.Tokens(), want) {
+		t.Fatalf("ParseFileParallel tokens differ from Parse: %d vs %d", len(mf.Tokens()), len(want))
+	}
+	// Split the mapped bytes regardless of the number of CPUs.
+	for _, workers := range []int{2, 3, 8} {
+		tokens, err := parseParallel(mf.Bytes(), 50000, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(tokens, want) {
+			t.Errorf("%d workers: tokens differ from Parse", workers)
+		}
+	}
+	if _, err := parseParallel(mf.Bytes(), len(want)-1, 4); err == nil {
+		t.Error("expected token overflow")
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ParseFile(filepath.Join(dir, "missing.json"), 10); err == nil {
+		t.Error("expected error for missing file")
+	}
+	path := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(path, []byte(`{"key": "value"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseFile(path, 10); err == nil {
+		t.Error("expected error for unclosed object")
+	}
+	empty := filepath.Join(dir, "empty.json")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mf, err := ParseFile(empty, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mf.Tokens()) != 0 {
+		t.Error("expected no tokens for empty file")
+	}
+	_ = mf.Close()
+}
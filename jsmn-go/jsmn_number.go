@@ -0,0 +1,280 @@
+package jsmngo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// NumberKind classifies a number literal by its syntax.
+type NumberKind int
+
+const (
+	// IntegerNumber is a literal without fraction or exponent, such as -12.
+	IntegerNumber NumberKind = iota
+	// FloatNumber is a literal with a fraction or exponent, such as 1.5e3.
+	FloatNumber
+)
+
+// maxDecimalExponent bounds the exponents that NumberDecimal and the integer
+// accessors will expand, so "1e999999999" cannot exhaust memory.
+const maxDecimalExponent = 1 << 14
+
+var (
+	// ErrNumberSyntax reports a token that does not match the RFC 8259 number grammar.
+	ErrNumberSyntax = errors.New("invalid JSON number")
+	// ErrNumberRange reports a number that does not fit the requested type.
+	ErrNumberRange = errors.New("number out of range")
+	// ErrNotInteger reports a number with a non-zero fractional part where an integer is required.
+	ErrNotInteger = errors.New("number is not an integer")
+)
+
+// NumberError describes a number token that could not be converted.
+type NumberError struct {
+	Literal string
+	Offset  int
+	Err     error // ErrNumberSyntax, ErrNumberRange or ErrNotInteger.
+}
+
+func (e *NumberError) Error() string {
+	return fmt.Sprintf("number %q at offset %d: %v", e.Literal, e.Offset, e.Err)
+}
+
+func (e *NumberError) Unwrap() error {
+	return e.Err
+}
+
+// NumberClass describes a number token and which Go types hold it.
+type NumberClass struct {
+	Kind        NumberKind
+	FitsInt64   bool // The value is an integer within int64 range.
+	FitsUint64  bool // The value is an integer within uint64 range.
+	FitsFloat64 bool // The value is finite as a float64, possibly rounded.
+}
+
+// ClassifyNumber checks tok against the number grammar and reports its kind
+// and range.
+func ClassifyNumber(json []byte, tok Token) (NumberClass, error) {
+	raw, err := numberLiteral(json, tok)
+	if err != nil {
+		return NumberClass{}, err
+	}
+	var c NumberClass
+	if bytes.ContainsAny(raw, ".eE") {
+		c.Kind = FloatNumber
+	}
+	_, err = NumberInt64(json, tok)
+	c.FitsInt64 = err == nil
+	_, err = NumberUint64(json, tok)
+	c.FitsUint64 = err == nil
+	_, err = NumberFloat64(json, tok)
+	c.FitsFloat64 = err == nil
+	return c, nil
+}
+
+// NumberInt64 returns tok as an int64. Literals such as 1e3 or 2.0 are
+// accepted when their value is integral.
+func NumberInt64(json []byte, tok Token) (int64, error) {
+	raw, err := numberLiteral(json, tok)
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.ContainsAny(raw, ".eE") {
+		i, err := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil {
+			return 0, numberError(raw, tok, ErrNumberRange)
+		}
+		return i, nil
+	}
+	n, err := bigInt(raw, tok)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsInt64() {
+		return 0, numberError(raw, tok, ErrNumberRange)
+	}
+	return n.Int64(), nil
+}
+
+// NumberUint64 returns tok as a uint64, under the same rules as NumberInt64.
+func NumberUint64(json []byte, tok Token) (uint64, error) {
+	raw, err := numberLiteral(json, tok)
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.ContainsAny(raw, ".eE-") {
+		u, err := strconv.ParseUint(string(raw), 10, 64)
+		if err != nil {
+			return 0, numberError(raw, tok, ErrNumberRange)
+		}
+		return u, nil
+	}
+	n, err := bigInt(raw, tok)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, numberError(raw, tok, ErrNumberRange)
+	}
+	return n.Uint64(), nil
+}
+
+// NumberFloat64 returns tok as the nearest float64. Values too large for a
+// float64 fail with ErrNumberRange; tiny values round to zero.
+func NumberFloat64(json []byte, tok Token) (float64, error) {
+	raw, err := numberLiteral(json, tok)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil && math.IsInf(f, 0) {
+		return 0, numberError(raw, tok, ErrNumberRange)
+	}
+	return f, nil
+}
+
+// NumberBigInt returns tok as a *big.Int. The value must be integral.
+func NumberBigInt(json []byte, tok Token) (*big.Int, error) {
+	raw, err := numberLiteral(json, tok)
+	if err != nil {
+		return nil, err
+	}
+	return bigInt(raw, tok)
+}
+
+// NumberBigFloat returns tok as a *big.Float rounded to prec bits of
+// mantissa. A prec of 0 picks 64, or more when the literal has more digits.
+func NumberBigFloat(json []byte, tok Token, prec uint) (*big.Float, error) {
+	raw, err := numberLiteral(json, tok)
+	if err != nil {
+		return nil, err
+	}
+	if prec == 0 {
+		prec = max(64, uint(float64(len(raw))*math.Log2(10))+1)
+	}
+	f, _, err := big.ParseFloat(string(raw), 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, numberError(raw, tok, ErrNumberRange)
+	}
+	return f, nil
+}
+
+// NumberDecimal returns tok as an exact plain decimal string without
+// exponent or redundant zeros: 1.50e2 becomes "150" and -0.0 becomes "0".
+func NumberDecimal(json []byte, tok Token) (string, error) {
+	raw, err := numberLiteral(json, tok)
+	if err != nil {
+		return "", err
+	}
+	neg, digits, exp, ok := parseDecimal(raw)
+	if !ok {
+		return "", numberError(raw, tok, ErrNumberRange)
+	}
+	var b strings.Builder
+	writeDecimal(&b, neg, digits, exp)
+	return b.String(), nil
+}
+
+// writeDecimal writes ±digits×10^exp, as returned by parseDecimal, as a plain
+// decimal.
+func writeDecimal(b *strings.Builder, neg bool, digits []byte, exp int) {
+	if len(digits) == 0 {
+		b.WriteByte('0')
+		return
+	}
+	if neg {
+		b.WriteByte('-')
+	}
+	switch point := len(digits) + exp; {
+	case exp >= 0:
+		b.Write(digits)
+		b.WriteString(strings.Repeat("0", exp))
+	case point > 0:
+		b.Write(digits[:point])
+		b.WriteByte('.')
+		b.Write(digits[point:])
+	default:
+		b.WriteString("0.")
+		b.WriteString(strings.Repeat("0", -point))
+		b.Write(digits)
+	}
+}
+
+func numberLiteral(json []byte, tok Token) ([]byte, error) {
+	if tok.Type != Primitive || tok.Start < 0 || tok.End > len(json) || tok.Start > tok.End {
+		return nil, &NumberError{Offset: tok.Start, Err: ErrNumberSyntax}
+	}
+	raw := json[tok.Start:tok.End]
+	if !isValidNumber(raw) {
+		return nil, numberError(raw, tok, ErrNumberSyntax)
+	}
+	return raw, nil
+}
+
+func numberError(raw []byte, tok Token, err error) error {
+	return &NumberError{Literal: string(raw), Offset: tok.Start, Err: err}
+}
+
+func bigInt(raw []byte, tok Token) (*big.Int, error) {
+	neg, digits, exp, ok := parseDecimal(raw)
+	if !ok {
+		return nil, numberError(raw, tok, ErrNumberRange)
+	}
+	if exp < 0 {
+		return nil, numberError(raw, tok, ErrNotInteger)
+	}
+	n := new(big.Int)
+	if len(digits) == 0 {
+		return n, nil
+	}
+	n.SetString(string(digits), 10)
+	if exp > 0 {
+		n.Mul(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+// parseDecimal splits a valid number literal into sign, significant digits
+// and a power of ten, so the value is ±digits×10^exp. Leading and trailing
+// zeros are stripped from digits; zero has no digits. ok is false when the
+// exponent exceeds maxDecimalExponent.
+func parseDecimal(raw []byte) (neg bool, digits []byte, exp int, ok bool) {
+	if raw[0] == '-' {
+		neg = true
+		raw = raw[1:]
+	}
+	mant := raw
+	if i := bytes.IndexAny(raw, "eE"); i >= 0 {
+		mant = raw[:i]
+		e, err := strconv.Atoi(strings.TrimPrefix(string(raw[i+1:]), "+"))
+		if err != nil || e > maxDecimalExponent || e < -maxDecimalExponent {
+			return false, nil, 0, false
+		}
+		exp = e
+	}
+	if i := bytes.IndexByte(mant, '.'); i >= 0 {
+		exp -= len(mant) - i - 1
+		digits = append(append(digits, mant[:i]...), mant[i+1:]...)
+	} else {
+		digits = append(digits, mant...)
+	}
+	digits = bytes.TrimLeft(digits, "0")
+	for len(digits) > 0 && digits[len(digits)-1] == '0' {
+		digits = digits[:len(digits)-1]
+		exp++
+	}
+	if len(digits) == 0 {
+		return false, nil, 0, true
+	}
+	if exp > maxDecimalExponent || exp < -maxDecimalExponent {
+		return false, nil, 0, false
+	}
+	return neg, digits, exp, true
+}
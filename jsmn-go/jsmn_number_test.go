@@ -0,0 +1,95 @@
+package jsmngo
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func numberToken(t *testing.T, lit string) ([]byte, Token) {
+	t.Helper()
+	json := []byte("[" + lit + "]")
+	p := NewParser(2)
+	if _, err := p.Parse(json); err != nil {
+		t.Fatal(err)
+	}
+	return json, p.Tokens()[1]
+}
+
+func TestNumberAccessors(t *testing.T) {
+	cases := []struct {
+		lit     string
+		kind    NumberKind
+		i64     int64
+		i64Err  error
+		u64Err  error
+		decimal string
+	}{
+		{"0", IntegerNumber, 0, nil, nil, "0"},
+		{"-0.0", FloatNumber, 0, nil, nil, "0"},
+		{"9007199254740993", IntegerNumber, 9007199254740993, nil, nil, "9007199254740993"},
+		{"-9223372036854775808", IntegerNumber, -9223372036854775808, nil, ErrNumberRange, "-9223372036854775808"},
+		{"18446744073709551615", IntegerNumber, 0, ErrNumberRange, nil, "18446744073709551615"},
+		{"1.50e2", FloatNumber, 150, nil, nil, "150"},
+		{"12.5", FloatNumber, 0, ErrNotInteger, ErrNotInteger, "12.5"},
+		{"-1.25E-3", FloatNumber, 0, ErrNotInteger, ErrNotInteger, "-0.00125"},
+		{"123456789012345678901234567890.000000000000000000001", FloatNumber, 0, ErrNotInteger, ErrNotInteger,
+			"123456789012345678901234567890.000000000000000000001"},
+	}
+	for _, c := range cases {
+		json, tok := numberToken(t, c.lit)
+		class, err := ClassifyNumber(json, tok)
+		if err != nil || class.Kind != c.kind || class.FitsInt64 != (c.i64Err == nil) || class.FitsUint64 != (c.u64Err == nil) {
+			t.Errorf("%s: unexpected class %+v (%v)", c.lit, class, err)
+		}
+		i, err := NumberInt64(json, tok)
+		if !errors.Is(err, c.i64Err) || (err == nil && i != c.i64) {
+			t.Errorf("%s: NumberInt64 = %d, %v", c.lit, i, err)
+		}
+		if _, err := NumberUint64(json, tok); !errors.Is(err, c.u64Err) {
+			t.Errorf("%s: NumberUint64 error %v, want %v", c.lit, err, c.u64Err)
+		}
+		if d, err := NumberDecimal(json, tok); err != nil || d != c.decimal {
+			t.Errorf("%s: NumberDecimal = %q, %v", c.lit, d, err)
+		}
+	}
+}
+
+func TestNumberBig(t *testing.T) {
+	json, tok := numberToken(t, "-123456789012345678901234567890e3")
+	n, err := NumberBigInt(json, tok)
+	if err != nil || n.String() != "-123456789012345678901234567890000" {
+		t.Errorf("NumberBigInt = %v, %v", n, err)
+	}
+	json, tok = numberToken(t, "0.1000000000000000000000000001")
+	f, err := NumberBigFloat(json, tok, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := new(big.Float).SetPrec(f.Prec()).SetString("0.1000000000000000000000000001")
+	if f.Cmp(want) != 0 || f.Text('g', 28) != "0.1000000000000000000000000001" {
+		t.Errorf("NumberBigFloat = %s", f.Text('g', 40))
+	}
+}
+
+func TestNumberErrors(t *testing.T) {
+	for _, lit := range []string{"01", "1.", ".5", "+1", "1e", "0x10", "Infinity", "NaN", "--1", "1_000"} {
+		json, tok := numberToken(t, lit)
+		if _, err := ClassifyNumber(json, tok); !errors.Is(err, ErrNumberSyntax) {
+			t.Errorf("%s: expected ErrNumberSyntax, got %v", lit, err)
+		}
+	}
+	json, tok := numberToken(t, "1e400")
+	if _, err := NumberFloat64(json, tok); !errors.Is(err, ErrNumberRange) {
+		t.Errorf("expected ErrNumberRange for float overflow, got %v", err)
+	}
+	json, tok = numberToken(t, "1e99999999")
+	var nerr *NumberError
+	if _, err := NumberDecimal(json, tok); !errors.As(err, &nerr) || nerr.Offset != 1 {
+		t.Errorf("expected NumberError at offset 1, got %v", err)
+	}
+	json, tok = numberToken(t, `"1"`)
+	if _, err := NumberInt64(json, tok); !errors.Is(err, ErrNumberSyntax) {
+		t.Errorf("expected ErrNumberSyntax for string token, got %v", err)
+	}
+}
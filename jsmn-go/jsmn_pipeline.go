@@ -0,0 +1,181 @@
+package jsmngo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// StreamValue is one complete top-level value produced by ParseStreamPipeline.
+// The final value on the channel carries Err when the stream failed.
+type StreamValue struct {
+	Offset int64   // Input offset of the value.
+	Raw    []byte  // Value bytes, owned by the receiver.
+	Tokens []Token // Tokens for Raw, with offsets relative to Raw.
+	Err    error
+}
+
+// PipelineOptions bounds the buffering of ParseStreamPipeline. Zero fields
+// take their defaults.
+type PipelineOptions struct {
+	BufferSize int // Size of each read buffer. Default 64 KiB.
+	Buffers    int // Buffers in the read-ahead ring. Default 4.
+	Values     int // Completed values queued for the receiver. Default 16.
+}
+
+type streamChunk struct {
+	buf  []byte // Ring buffer to hand back once consumed.
+	data []byte
+	err  error
+}
+
+// ParseStreamPipeline tokenizes a stream of concatenated or newline-delimited
+// JSON values, overlapping I/O with tokenizing. One goroutine reads ahead
+// into a fixed ring of buffers while another scans them with a resumable
+// scanner, so values may span buffer boundaries. When the receiver falls
+// behind, the ring fills and reading pauses. The channel is closed after the
+// last value or error; cancel ctx to stop early. A Read already blocked in r
+// is not interrupted by cancellation.
+func ParseStreamPipeline(ctx context.Context, r io.Reader, opts PipelineOptions) <-chan StreamValue {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 64 << 10
+	}
+	if opts.Buffers <= 0 {
+		opts.Buffers = 4
+	}
+	if opts.Values <= 0 {
+		opts.Values = 16
+	}
+	free := make(chan []byte, opts.Buffers)
+	for i := 0; i < opts.Buffers; i++ {
+		free <- make([]byte, opts.BufferSize)
+	}
+	filled := make(chan streamChunk, opts.Buffers)
+	out := make(chan StreamValue, opts.Values)
+	// The tokenizer cancels ctx when it stops, including on a syntax error,
+	// so the reader does not block forever on a full ring.
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer close(filled)
+		for {
+			var buf []byte
+			select {
+			case buf = <-free:
+			case <-ctx.Done():
+				return
+			}
+			n, err := r.Read(buf)
+			select {
+			case filled <- streamChunk{buf: buf, data: buf[:n], err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(out)
+		defer cancel()
+		t := &streamTokenizer{done: ctx.Done(), out: out, p: NewParser(0)}
+		for ch := range filled {
+			ok := t.feed(ch.data)
+			free <- ch.buf
+			if !ok {
+				return
+			}
+			if ch.err != nil {
+				t.finish(ch.err)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// streamTokenizer splits chunks into top-level values and tokenizes each.
+type streamTokenizer struct {
+	done    <-chan struct{}
+	out     chan<- StreamValue
+	p       *Parser
+	scan    valueScanner
+	inValue bool
+	cur     []byte // Bytes of the value being scanned.
+	start   int64  // Input offset of cur.
+	pos     int64  // Input offset of the next byte.
+}
+
+// feed consumes one chunk. It returns false once the pipeline should stop.
+func (t *streamTokenizer) feed(b []byte) bool {
+	for len(b) > 0 {
+		if !t.inValue {
+			i := 0
+			for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\r' || b[i] == '\n') {
+				i++
+			}
+			b = b[i:]
+			t.pos += int64(i)
+			if len(b) == 0 {
+				break
+			}
+			switch c := b[0]; {
+			case c == '{', c == '[', c == '"', c == '-', c == 't', c == 'f', c == 'n', isDigit(c):
+			default:
+				msg := fmt.Sprintf("invalid character %q looking for beginning of value", c)
+				t.send(StreamValue{Err: &SyntaxError{Msg: msg, Offset: t.pos}})
+				return false
+			}
+			t.inValue, t.scan, t.start = true, valueScanner{}, t.pos
+		}
+		n, done := t.scan.feed(b)
+		t.cur = append(t.cur, b[:n]...)
+		b = b[n:]
+		t.pos += int64(n)
+		if done && !t.emit() {
+			return false
+		}
+	}
+	return true
+}
+
+// finish handles the read error that ended the stream.
+func (t *streamTokenizer) finish(err error) {
+	if !errors.Is(err, io.EOF) {
+		t.send(StreamValue{Err: err})
+		return
+	}
+	if !t.inValue {
+		return
+	}
+	if t.scan.primitive {
+		t.emit()
+		return
+	}
+	t.send(StreamValue{Err: io.ErrUnexpectedEOF, Offset: t.start})
+}
+
+func (t *streamTokenizer) emit() bool {
+	raw := t.cur
+	t.cur, t.inValue = nil, false
+	if n := len(raw)/2 + 1; len(t.p.tokens) < n {
+		t.p.tokens = make([]Token, n)
+	}
+	if _, err := t.p.Parse(raw); err != nil {
+		t.send(StreamValue{Offset: t.start, Err: err})
+		return false
+	}
+	return t.send(StreamValue{Offset: t.start, Raw: raw, Tokens: slices.Clone(t.p.Tokens())})
+}
+
+func (t *streamTokenizer) send(v StreamValue) bool {
+	select {
+	case t.out <- v:
+		return v.Err == nil
+	case <-t.done:
+		return false
+	}
+}
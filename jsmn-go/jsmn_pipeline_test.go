@@ -0,0 +1,128 @@
+package jsmngo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestParseStreamPipeline(t *testing.T) {
+	in := "{\"a\": [1, 2]}\n\"str\\\"ing\" 42\n[{\"b\": null}, true]   -1.5e3"
+	ch := ParseStreamPipeline(context.Background(), iotest.OneByteReader(strings.NewReader(in)),
+		PipelineOptions{BufferSize: 3, Buffers: 2, Values: 1})
+	var raws []string
+	for v := range ch {
+		if v.Err != nil {
+			t.Fatal(v.Err)
+		}
+		if string(v.Raw) != in[v.Offset:v.Offset+int64(len(v.Raw))] {
+			t.Errorf("value at %d does not match input: %q", v.Offset, v.Raw)
+		}
+		p := NewParser(16)
+		if _, err := p.Parse(v.Raw); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v.Tokens, p.Tokens()) {
+			t.Errorf("tokens for %q differ from Parse", v.Raw)
+		}
+		raws = append(raws, string(v.Raw))
+	}
+	want := []string{`{"a": [1, 2]}`, `"str\"ing"`, `42`, `[{"b": null}, true]`, `-1.5e3`}
+	if !reflect.DeepEqual(raws, want) {
+		t.Errorf("expected %q, got %q", want, raws)
+	}
+}
+
+func TestParseStreamPipelineErrors(t *testing.T) {
+	for in, want := range map[string]error{
+		`{"a": 1} {"b": `: io.ErrUnexpectedEOF,
+		`[1] ]`:           &SyntaxError{},
+	} {
+		var last StreamValue
+		for v := range ParseStreamPipeline(context.Background(), strings.NewReader(in), PipelineOptions{}) {
+			last = v
+		}
+		if last.Err == nil || reflect.TypeOf(last.Err) != reflect.TypeOf(want) {
+			t.Errorf("%s: expected %T, got %v", in, want, last.Err)
+		}
+	}
+	readErr := errors.New("read failed")
+	var last StreamValue
+	for v := range ParseStreamPipeline(context.Background(), iotest.ErrReader(readErr), PipelineOptions{}) {
+		last = v
+	}
+	if !errors.Is(last.Err, readErr) {
+		t.Errorf("expected read error, got %v", last.Err)
+	}
+}
+
+func TestParseStreamPipelineCancel(t *testing.T) {
+	in := strings.Repeat(`{"id": 1} `, 10000)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := ParseStreamPipeline(ctx, strings.NewReader(in), PipelineOptions{BufferSize: 64, Buffers: 1, Values: 1})
+	<-ch
+	cancel()
+	n := 0
+	for range ch {
+		n++
+	}
+	if n > 3 {
+		t.Errorf("expected the pipeline to stop after cancel, got %d more values", n)
+	}
+}
+
+func TestParseStreamPipelineStopsReaderOnError(t *testing.T) {
+	before := runtime.NumGoroutine()
+	r := io.MultiReader(strings.NewReader("1 ]"), endlessSpaces{})
+	var last StreamValue
+	for v := range ParseStreamPipeline(context.Background(), r, PipelineOptions{BufferSize: 16, Buffers: 2}) {
+		last = v
+	}
+	var syn *SyntaxError
+	if !errors.As(last.Err, &syn) {
+		t.Fatalf("expected SyntaxError, got %v", last.Err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("reader goroutine still running: %d goroutines, started with %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// endlessSpaces is a reader that never ends.
+type endlessSpaces struct{}
+
+func (endlessSpaces) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = ' '
+	}
+	return len(b), nil
+}
+
+func TestParseStreamOffsets(t *testing.T) {
+	in := fmt.Sprintf(`{"key": "value", "arr": [%s1]}`, strings.Repeat("1, ", 5000))
+	p := NewParser(6000)
+	if _, err := p.Parse([]byte(in)); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := ParseStream(strings.NewReader(in+"\n[true]"), 6002)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tokens[:len(tokens)-2], p.Tokens()) {
+		t.Error("stream tokens differ from Parse")
+	}
+	tail := tokens[len(tokens)-2:]
+	if tail[0].Type != Array || tail[0].Start != len(in)+1 || tail[1].ParentIdx != len(tokens)-2 {
+		t.Errorf("unexpected tokens for second value: %v", tail)
+	}
+}
@@ -0,0 +1,646 @@
+package jsmngo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Type bits for the "type" keyword.
+const (
+	typeNull = 1 << iota
+	typeBoolean
+	typeInteger
+	typeNumber
+	typeString
+	typeObject
+	typeArray
+)
+
+var schemaTypes = map[string]int{
+	"null":    typeNull,
+	"boolean": typeBoolean,
+	"integer": typeInteger,
+	"number":  typeNumber,
+	"string":  typeString,
+	"object":  typeObject,
+	"array":   typeArray,
+}
+
+// Schema is a compiled JSON Schema. It supports a subset of draft 2020-12:
+// type, properties, required, items, enum, const, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, minLength, maxLength, minItems,
+// maxItems, pattern, additionalProperties and $ref to locations within the
+// same schema document. Other keywords are ignored. Patterns use Go's RE2
+// syntax, so ECMA-262 features such as lookahead are not available.
+type Schema struct {
+	root *schemaNode
+}
+
+type schemaNode struct {
+	always *bool // Set for the boolean schemas true and false.
+	ref    *schemaNode
+
+	types      int
+	properties map[string]*schemaNode
+	required   []string
+	additional *schemaNode // Applies to members not in properties.
+	items      *schemaNode
+
+	enum     map[string]bool // Canonical encodings of the allowed values.
+	constVal *string         // Canonical encoding of the only allowed value.
+
+	minimum, maximum           *float64
+	exclusiveMin, exclusiveMax *float64
+	minLength, maxLength       int // -1 when unset.
+	minItems, maxItems         int // -1 when unset.
+	pattern                    *regexp.Regexp
+}
+
+// ValidationFailure is one schema violation.
+type ValidationFailure struct {
+	Path    string // JSON Pointer of the offending value in the instance.
+	Keyword string // Schema keyword that failed.
+	Message string
+}
+
+// ValidationError lists every violation found by Schema.Validate.
+type ValidationError struct {
+	Failures []ValidationFailure
+}
+
+func (e *ValidationError) Error() string {
+	f := e.Failures[0]
+	msg := fmt.Sprintf("schema validation failed at %q: %s: %s", f.Path, f.Keyword, f.Message)
+	if len(e.Failures) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Failures)-1)
+	}
+	return msg
+}
+
+// CompileSchema compiles a JSON Schema document.
+func CompileSchema(schema []byte) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(schema))
+	dec.UseNumber()
+	var root any
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	c := &schemaCompiler{root: root, nodes: make(map[string]*schemaNode)}
+	n, err := c.compile(root, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkRefCycles(); err != nil {
+		return nil, err
+	}
+	return &Schema{root: n}, nil
+}
+
+type schemaCompiler struct {
+	root  any
+	nodes map[string]*schemaNode // Keyed by JSON Pointer into the schema document.
+}
+
+func (c *schemaCompiler) compile(v any, ptr string) (*schemaNode, error) {
+	if n, ok := c.nodes[ptr]; ok {
+		return n, nil
+	}
+	n := &schemaNode{minLength: -1, maxLength: -1, minItems: -1, maxItems: -1}
+	c.nodes[ptr] = n // Registered first so recursive references terminate.
+	var m map[string]any
+	switch s := v.(type) {
+	case bool:
+		n.always = &s
+		return n, nil
+	case map[string]any:
+		m = s
+	default:
+		return nil, fmt.Errorf("schema at %q must be an object or boolean", ptr)
+	}
+	for _, kw := range sortedKeys(m) {
+		if err := c.keyword(n, kw, m[kw], ptr+"/"+pointerEscaper.Replace(kw)); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// keyword compiles one schema keyword into n. ptr locates the keyword's value.
+func (c *schemaCompiler) keyword(n *schemaNode, kw string, v any, ptr string) error {
+	var err error
+	switch kw {
+	case "$ref":
+		n.ref, err = c.ref(v, ptr)
+	case "type":
+		n.types, err = schemaTypeMask(v, ptr)
+	case "properties":
+		m, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("schema %q must be an object", ptr)
+		}
+		n.properties = make(map[string]*schemaNode, len(m))
+		for _, name := range sortedKeys(m) {
+			if n.properties[name], err = c.compile(m[name], ptr+"/"+pointerEscaper.Replace(name)); err != nil {
+				return err
+			}
+		}
+	case "required":
+		n.required, err = stringList(v, ptr)
+	case "additionalProperties":
+		n.additional, err = c.compile(v, ptr)
+	case "items":
+		n.items, err = c.compile(v, ptr)
+	case "enum":
+		list, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("schema %q must be an array", ptr)
+		}
+		n.enum = make(map[string]bool, len(list))
+		for _, e := range list {
+			s, err := canonicalAny(e)
+			if err != nil {
+				return fmt.Errorf("schema %q: %w", ptr, err)
+			}
+			n.enum[s] = true
+		}
+	case "const":
+		s, err := canonicalAny(v)
+		if err != nil {
+			return fmt.Errorf("schema %q: %w", ptr, err)
+		}
+		n.constVal = &s
+	case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+		num, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("schema %q must be a number", ptr)
+		}
+		f, err := num.Float64()
+		if err != nil {
+			return fmt.Errorf("schema %q: %w", ptr, err)
+		}
+		switch kw {
+		case "minimum":
+			n.minimum = &f
+		case "maximum":
+			n.maximum = &f
+		case "exclusiveMinimum":
+			n.exclusiveMin = &f
+		default:
+			n.exclusiveMax = &f
+		}
+	case "minLength", "maxLength", "minItems", "maxItems":
+		num, ok := v.(json.Number)
+		i, err := num.Int64()
+		if !ok || err != nil || i < 0 {
+			return fmt.Errorf("schema %q must be a non-negative integer", ptr)
+		}
+		switch kw {
+		case "minLength":
+			n.minLength = int(i)
+		case "maxLength":
+			n.maxLength = int(i)
+		case "minItems":
+			n.minItems = int(i)
+		default:
+			n.maxItems = int(i)
+		}
+	case "pattern":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("schema %q must be a string", ptr)
+		}
+		if n.pattern, err = regexp.Compile(s); err != nil {
+			return fmt.Errorf("schema %q: %w", ptr, err)
+		}
+	}
+	return err
+}
+
+// ref resolves a "#..." reference against the schema document.
+func (c *schemaCompiler) ref(v any, ptr string) (*schemaNode, error) {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, "#") {
+		return nil, fmt.Errorf("schema %q: only references within the document are supported", ptr)
+	}
+	frag, err := url.PathUnescape(s[1:])
+	if err != nil {
+		return nil, fmt.Errorf("schema %q: %w", ptr, err)
+	}
+	if frag != "" && frag[0] != '/' {
+		return nil, fmt.Errorf("schema %q: unsupported reference %q", ptr, s)
+	}
+	target := c.root
+	if frag != "" {
+		for _, seg := range strings.Split(frag[1:], "/") {
+			seg = strings.NewReplacer("~1", "/", "~0", "~").Replace(seg)
+			switch t := target.(type) {
+			case map[string]any:
+				target, ok = t[seg]
+			case []any:
+				i, err := strconv.Atoi(seg)
+				ok = err == nil && i >= 0 && i < len(t)
+				if ok {
+					target = t[i]
+				}
+			default:
+				ok = false
+			}
+			if !ok {
+				return nil, fmt.Errorf("schema %q: unresolved reference %q", ptr, s)
+			}
+		}
+	}
+	return c.compile(target, frag)
+}
+
+// checkRefCycles rejects $ref chains that lead back to where they started.
+// Such a chain applies to one instance value without ever descending into
+// it, so validation could not terminate.
+func (c *schemaCompiler) checkRefCycles() error {
+	ptrs := make([]string, 0, len(c.nodes))
+	for ptr := range c.nodes {
+		ptrs = append(ptrs, ptr)
+	}
+	slices.Sort(ptrs)
+	for _, ptr := range ptrs {
+		seen := make(map[*schemaNode]bool)
+		for n := c.nodes[ptr]; n != nil; n = n.ref {
+			if seen[n] {
+				return fmt.Errorf("schema %q: $ref cycle does not descend into the instance", ptr)
+			}
+			seen[n] = true
+		}
+	}
+	return nil
+}
+
+func schemaTypeMask(v any, ptr string) (int, error) {
+	names, ok := v.([]any)
+	if s, isString := v.(string); isString {
+		names, ok = []any{s}, true
+	}
+	if !ok {
+		return 0, fmt.Errorf("schema %q must be a string or array", ptr)
+	}
+	mask := 0
+	for _, name := range names {
+		s, _ := name.(string)
+		bit, ok := schemaTypes[s]
+		if !ok {
+			return 0, fmt.Errorf("schema %q: unknown type %v", ptr, name)
+		}
+		mask |= bit
+	}
+	return mask, nil
+}
+
+func stringList(v any, ptr string) ([]string, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("schema %q must be an array of strings", ptr)
+	}
+	out := make([]string, len(list))
+	for i, e := range list {
+		if out[i], ok = e.(string); !ok {
+			return nil, fmt.Errorf("schema %q must be an array of strings", ptr)
+		}
+	}
+	return out, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Validate checks the first top-level value in tokens, which must come from
+// tokenizing json, and returns a *ValidationError listing every failure.
+func (s *Schema) Validate(json []byte, tokens []Token) error {
+	if len(tokens) == 0 {
+		return errors.New("no value to validate")
+	}
+	v := &validator{json: json, tokens: tokens}
+	v.validate(s.root, 0)
+	if len(v.failures) > 0 {
+		return &ValidationError{Failures: v.failures}
+	}
+	return nil
+}
+
+type validator struct {
+	json     []byte
+	tokens   []Token
+	path     []string
+	failures []ValidationFailure
+}
+
+func (v *validator) fail(keyword, format string, args ...any) {
+	var b strings.Builder
+	for _, seg := range v.path {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(seg))
+	}
+	v.failures = append(v.failures, ValidationFailure{Path: b.String(), Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate checks token i against n and returns the index after its subtree.
+func (v *validator) validate(n *schemaNode, i int) int {
+	if n.ref != nil {
+		v.validate(n.ref, i)
+	}
+	if n.always != nil {
+		if !*n.always {
+			v.fail("false", "no value is allowed here")
+		}
+		return skipToken(v.tokens, i)
+	}
+	tok := v.tokens[i]
+	kind, num, err := v.kind(tok)
+	if err != nil {
+		v.fail("type", "%v", err)
+		return skipToken(v.tokens, i)
+	}
+	if n.types != 0 && n.types&kind == 0 && !(kind == typeInteger && n.types&typeNumber != 0) {
+		v.fail("type", "expected %s, got %s", typeNames(n.types), typeNames(kind))
+	}
+	if n.enum != nil || n.constVal != nil {
+		c, _, err := canonical(v.json, v.tokens, i)
+		switch {
+		case err != nil:
+			v.fail("enum", "%v", err)
+		case n.enum != nil && !n.enum[c]:
+			v.fail("enum", "value %s is not one of the allowed values", c)
+		case n.constVal != nil && c != *n.constVal:
+			v.fail("const", "value %s does not equal %s", c, *n.constVal)
+		}
+	}
+	switch kind {
+	case typeInteger, typeNumber:
+		v.checkNumber(n, num)
+	case typeString:
+		v.checkString(n, tok)
+	case typeObject:
+		return v.validateObject(n, i)
+	case typeArray:
+		return v.validateArray(n, i)
+	}
+	return i + 1
+}
+
+// kind classifies a token for the type keyword and parses numbers.
+func (v *validator) kind(tok Token) (int, float64, error) {
+	switch tok.Type {
+	case Object:
+		return typeObject, 0, nil
+	case Array:
+		return typeArray, 0, nil
+	case String:
+		return typeString, 0, nil
+	}
+	raw := v.json[tok.Start:tok.End]
+	switch string(raw) {
+	case "null":
+		return typeNull, 0, nil
+	case "true", "false":
+		return typeBoolean, 0, nil
+	}
+	if !isValidNumber(raw) {
+		return 0, 0, fmt.Errorf("invalid primitive %q", raw)
+	}
+	f, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("number %s: %w", raw, err)
+	}
+	if f == math.Trunc(f) {
+		return typeInteger, f, nil
+	}
+	return typeNumber, f, nil
+}
+
+func (v *validator) checkNumber(n *schemaNode, f float64) {
+	if n.minimum != nil && f < *n.minimum {
+		v.fail("minimum", "%g is less than %g", f, *n.minimum)
+	}
+	if n.maximum != nil && f > *n.maximum {
+		v.fail("maximum", "%g is greater than %g", f, *n.maximum)
+	}
+	if n.exclusiveMin != nil && f <= *n.exclusiveMin {
+		v.fail("exclusiveMinimum", "%g is not greater than %g", f, *n.exclusiveMin)
+	}
+	if n.exclusiveMax != nil && f >= *n.exclusiveMax {
+		v.fail("exclusiveMaximum", "%g is not less than %g", f, *n.exclusiveMax)
+	}
+}
+
+func (v *validator) checkString(n *schemaNode, tok Token) {
+	if n.minLength < 0 && n.maxLength < 0 && n.pattern == nil {
+		return
+	}
+	s, err := unescape(v.json[tok.Start:tok.End])
+	if err != nil {
+		v.fail("type", "%v", err)
+		return
+	}
+	if l := utf8.RuneCount(s); n.minLength >= 0 && l < n.minLength {
+		v.fail("minLength", "length %d is less than %d", l, n.minLength)
+	} else if n.maxLength >= 0 && l > n.maxLength {
+		v.fail("maxLength", "length %d is greater than %d", l, n.maxLength)
+	}
+	if n.pattern != nil && !n.pattern.Match(s) {
+		v.fail("pattern", "%q does not match %q", s, n.pattern)
+	}
+}
+
+func (v *validator) validateObject(n *schemaNode, i int) int {
+	size := v.tokens[i].Size
+	var seen map[string]bool
+	if len(n.required) > 0 {
+		seen = make(map[string]bool, size/2)
+	}
+	next := i + 1
+	for c := 0; c+1 < size; c += 2 {
+		name := keyText(v.json, v.tokens[next])
+		if seen != nil {
+			seen[name] = true
+		}
+		sub, ok := n.properties[name]
+		if !ok {
+			sub = n.additional
+		}
+		v.path = append(v.path, name)
+		switch {
+		case sub == nil:
+			next = skipToken(v.tokens, next+1)
+		case !ok && sub.always != nil && !*sub.always:
+			v.fail("additionalProperties", "property %q is not allowed", name)
+			next = skipToken(v.tokens, next+1)
+		default:
+			next = v.validate(sub, next+1)
+		}
+		v.path = v.path[:len(v.path)-1]
+	}
+	for _, name := range n.required {
+		if !seen[name] {
+			v.fail("required", "missing property %q", name)
+		}
+	}
+	return next
+}
+
+func (v *validator) validateArray(n *schemaNode, i int) int {
+	size := v.tokens[i].Size
+	if n.minItems >= 0 && size < n.minItems {
+		v.fail("minItems", "%d items is fewer than %d", size, n.minItems)
+	}
+	if n.maxItems >= 0 && size > n.maxItems {
+		v.fail("maxItems", "%d items is more than %d", size, n.maxItems)
+	}
+	if n.items == nil {
+		return skipToken(v.tokens, i)
+	}
+	next := i + 1
+	for c := 0; c < size; c++ {
+		v.path = append(v.path, strconv.Itoa(c))
+		next = v.validate(n.items, next)
+		v.path = v.path[:len(v.path)-1]
+	}
+	return next
+}
+
+func typeNames(mask int) string {
+	var names []string
+	for name, bit := range schemaTypes {
+		if mask&bit != 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return strings.Join(names, " or ")
+}
+
+// canonical returns an encoding of the value at token i in which equal JSON
+// values encode identically: keys sorted, numbers normalized, strings
+// re-quoted. It also returns the index after the value's subtree.
+func canonical(json []byte, tokens []Token, i int) (string, int, error) {
+	var b strings.Builder
+	next, err := writeCanonical(&b, json, tokens, i)
+	return b.String(), next, err
+}
+
+func writeCanonical(b *strings.Builder, json []byte, tokens []Token, i int) (int, error) {
+	tok := tokens[i]
+	switch tok.Type {
+	case String:
+		s, err := unescape(json[tok.Start:tok.End])
+		if err != nil {
+			return 0, err
+		}
+		b.WriteString(strconv.Quote(string(s)))
+		return i + 1, nil
+	case Array:
+		b.WriteByte('[')
+		next := i + 1
+		for c := 0; c < tok.Size; c++ {
+			if c > 0 {
+				b.WriteByte(',')
+			}
+			var err error
+			if next, err = writeCanonical(b, json, tokens, next); err != nil {
+				return 0, err
+			}
+		}
+		b.WriteByte(']')
+		return next, nil
+	case Object:
+		type member struct{ key, val string }
+		members := make([]member, 0, tok.Size/2)
+		next := i + 1
+		for c := 0; c+1 < tok.Size; c += 2 {
+			key, _, err := canonical(json, tokens, next)
+			if err != nil {
+				return 0, err
+			}
+			var val string
+			if val, next, err = canonical(json, tokens, next+1); err != nil {
+				return 0, err
+			}
+			members = append(members, member{key, val})
+		}
+		slices.SortFunc(members, func(a, b member) int { return strings.Compare(a.key, b.key) })
+		b.WriteByte('{')
+		for c, m := range members {
+			if c > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(m.key)
+			b.WriteByte(':')
+			b.WriteString(m.val)
+		}
+		b.WriteByte('}')
+		return next, nil
+	}
+	raw := json[tok.Start:tok.End]
+	switch string(raw) {
+	case "null", "true", "false":
+		b.Write(raw)
+		return i + 1, nil
+	}
+	if !isValidNumber(raw) {
+		return 0, fmt.Errorf("invalid primitive %q", raw)
+	}
+	writeCanonicalNumber(b, raw)
+	return i + 1, nil
+}
+
+// writeCanonicalNumber writes the exact value of a number literal, so that
+// 1, 1.0 and 1e0 encode identically while integers beyond float64 precision
+// stay distinct. Values far from 1 use an exponent.
+func writeCanonicalNumber(b *strings.Builder, raw []byte) {
+	neg, digits, exp, ok := parseDecimal(raw)
+	if !ok {
+		b.Write(raw) // Exponent beyond maxDecimalExponent: compare as written.
+		return
+	}
+	if point := len(digits) + exp; len(digits) == 0 || point > -6 && point <= 21 {
+		writeDecimal(b, neg, digits, exp)
+		return
+	}
+	if neg {
+		b.WriteByte('-')
+	}
+	b.WriteByte(digits[0])
+	if len(digits) > 1 {
+		b.WriteByte('.')
+		b.Write(digits[1:])
+	}
+	b.WriteByte('e')
+	b.WriteString(strconv.Itoa(exp + len(digits) - 1))
+}
+
+// canonicalAny encodes a decoded schema value the same way canonical encodes
+// an instance value.
+func canonicalAny(v any) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encode value: %w", err)
+	}
+	p := NewParser(len(raw)/2 + 1)
+	if _, err := p.Parse(raw); err != nil {
+		return "", err
+	}
+	s, _, err := canonical(raw, p.Tokens(), 0)
+	return s, err
+}
@@ -0,0 +1,145 @@
+package jsmngo
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+const testSchema = `{
+  "$defs": {
+    "tag": {"type": "string", "pattern": "^[a-z]+$", "maxLength": 5},
+    "node": {
+      "type": "object",
+      "properties": {"value": {"type": "integer"}, "children": {"type": "array", "items": {"$ref": "#/$defs/node"}}},
+      "required": ["value"]
+    }
+  },
+  "type": "object",
+  "properties": {
+    "id": {"type": "integer", "minimum": 1, "exclusiveMaximum": 100},
+    "kind": {"enum": ["a", "b", {"x": [1, 2]}]},
+    "version": {"const": 2},
+    "tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}, "minItems": 1, "maxItems": 3},
+    "tree": {"$ref": "#/$defs/node"},
+    "meta": {"type": ["object", "null"], "additionalProperties": {"type": "string"}}
+  },
+  "required": ["id", "kind"],
+  "additionalProperties": false
+}`
+
+func validateJSON(t *testing.T, s *Schema, json string) error {
+	t.Helper()
+	p := NewParser(128)
+	if _, err := p.Parse([]byte(json)); err != nil {
+		t.Fatal(err)
+	}
+	return s.Validate([]byte(json), p.Tokens())
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s, err := CompileSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := []string{
+		`{"id": 1, "kind": "a"}`,
+		`{"id": 99.0, "kind": {"x": [1, 2.0]}, "version": 2.0, "tags": ["ab"], "meta": null}`,
+		`{"id": 5, "kind": "b", "tree": {"value": 1, "children": [{"value": 2, "children": []}]}, "meta": {"k": "v"}}`,
+	}
+	for _, json := range valid {
+		if err := validateJSON(t, s, json); err != nil {
+			t.Errorf("%s: unexpected error %v", json, err)
+		}
+	}
+
+	err = validateJSON(t, s, `{"id": 100, "kind": "c", "version": 3, "tags": ["ABC", "toolong", 1, "a"],
+		"tree": {"children": [{"value": "x"}]}, "meta": {"k": 1}, "extra/x": true}`)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	var got []string
+	for _, f := range verr.Failures {
+		got = append(got, f.Path+" "+f.Keyword)
+	}
+	slices.Sort(got)
+	want := []string{
+		"/extra~1x additionalProperties",
+		"/id exclusiveMaximum",
+		"/kind enum",
+		"/meta/k type",
+		"/tags maxItems",
+		"/tags/0 pattern",
+		"/tags/1 maxLength",
+		"/tags/2 type",
+		"/tree required",
+		"/tree/children/0/value type",
+		"/version const",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected failures:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestCompileSchemaErrors(t *testing.T) {
+	for _, schema := range []string{
+		`{"type": "bogus"}`,
+		`{"$ref": "other.json#/x"}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"pattern": "("}`,
+		`{"minLength": -1}`,
+		`[1]`,
+		`{"$ref": "#"}`,
+		`{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "properties": {"x": {"$ref": "#/$defs/a"}}}`,
+		`{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/c"}, "c": {"$ref": "#/$defs/b"}}, "$ref": "#/$defs/a"}`,
+	} {
+		if _, err := CompileSchema([]byte(schema)); err == nil {
+			t.Errorf("%s: expected compile error", schema)
+		}
+	}
+}
+
+func TestSchemaRecursiveRefsCompile(t *testing.T) {
+	s, err := CompileSchema([]byte(`{"type": "array", "items": {"$ref": "#"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateJSON(t, s, `[[], [[[]]]]`); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := validateJSON(t, s, `[[1]]`); err == nil {
+		t.Error("expected a type failure")
+	}
+}
+
+func TestSchemaEnumExactNumbers(t *testing.T) {
+	s, err := CompileSchema([]byte(`{"properties": {
+		"a": {"enum": [9007199254740993, 1.5, 100]},
+		"b": {"const": 12345678901234567890123}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, json := range []string{
+		`{"a": 9007199254740993}`,
+		`{"a": 9.007199254740993e15}`,
+		`{"a": 15e-1}`,
+		`{"a": 1.00e2}`,
+		`{"b": 12345678901234567890123.0}`,
+	} {
+		if err := validateJSON(t, s, json); err != nil {
+			t.Errorf("%s: unexpected error %v", json, err)
+		}
+	}
+	for _, json := range []string{
+		`{"a": 9007199254740992}`,
+		`{"a": 9007199254740994}`,
+		`{"a": 1.50000000000000001}`,
+		`{"b": 12345678901234567890124}`,
+	} {
+		if err := validateJSON(t, s, json); err == nil {
+			t.Errorf("%s: expected a failure", json)
+		}
+	}
+}
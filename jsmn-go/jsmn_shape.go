@@ -0,0 +1,334 @@
+package jsmngo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// Shape is the structure inferred from one or more JSON values observed at
+// the same path. The zero value is an empty shape ready for Add.
+type Shape struct {
+	Count int // Values observed.
+
+	// Observations per JSON type.
+	Null, Bool, Integer, Number, String, Object, Array int
+
+	MinNumber, MaxNumber float64 // Range of Integer and Number values.
+	MinLength, MaxLength int     // Range of String lengths, in characters.
+	MinItems, MaxItems   int     // Range of Array lengths.
+
+	// Properties holds member shapes. A property is required when its Count
+	// equals the parent's Object count.
+	Properties map[string]*Shape
+	Items      *Shape // Shape of all array elements combined.
+}
+
+// Add records every top-level value in tokens, which must come from
+// tokenizing json. Tokenizing NDJSON in one Parse call yields one top-level
+// value per line.
+func (s *Shape) Add(json []byte, tokens []Token) error {
+	for i := 0; i < len(tokens); {
+		next, err := s.add(json, tokens, i)
+		if err != nil {
+			return err
+		}
+		i = next
+	}
+	return nil
+}
+
+func (s *Shape) add(json []byte, tokens []Token, i int) (int, error) {
+	tok := tokens[i]
+	s.Count++
+	switch tok.Type {
+	case Object:
+		s.Object++
+		if s.Properties == nil {
+			s.Properties = make(map[string]*Shape)
+		}
+		next := i + 1
+		for c := 0; c+1 < tok.Size; c += 2 {
+			name := keyText(json, tokens[next])
+			prop := s.Properties[name]
+			if prop == nil {
+				prop = &Shape{}
+				s.Properties[name] = prop
+			}
+			var err error
+			if next, err = prop.add(json, tokens, next+1); err != nil {
+				return 0, err
+			}
+		}
+		return next, nil
+	case Array:
+		s.Array++
+		s.MinItems, s.MaxItems = observe(s.Array, s.MinItems, s.MaxItems, tok.Size)
+		if s.Items == nil {
+			s.Items = &Shape{}
+		}
+		next := i + 1
+		for c := 0; c < tok.Size; c++ {
+			var err error
+			if next, err = s.Items.add(json, tokens, next); err != nil {
+				return 0, err
+			}
+		}
+		return next, nil
+	case String:
+		str, err := unescape(json[tok.Start:tok.End])
+		if err != nil {
+			return 0, fmt.Errorf("string at offset %d: %w", tok.Start, err)
+		}
+		s.String++
+		s.MinLength, s.MaxLength = observe(s.String, s.MinLength, s.MaxLength, utf8.RuneCount(str))
+		return i + 1, nil
+	}
+	raw := json[tok.Start:tok.End]
+	switch string(raw) {
+	case "null":
+		s.Null++
+		return i + 1, nil
+	case "true", "false":
+		s.Bool++
+		return i + 1, nil
+	}
+	if !isValidNumber(raw) {
+		return 0, fmt.Errorf("invalid primitive %q at offset %d", raw, tok.Start)
+	}
+	f, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil {
+		return 0, fmt.Errorf("number at offset %d: %w", tok.Start, err)
+	}
+	if bytes.ContainsAny(raw, ".eE") {
+		s.Number++
+	} else {
+		s.Integer++
+	}
+	if s.Integer+s.Number == 1 {
+		s.MinNumber, s.MaxNumber = f, f
+	} else {
+		s.MinNumber, s.MaxNumber = min(s.MinNumber, f), max(s.MaxNumber, f)
+	}
+	return i + 1, nil
+}
+
+// observe folds v into a running range; n counts observations including v.
+func observe(n, lo, hi, v int) (int, int) {
+	if n == 1 {
+		return v, v
+	}
+	return min(lo, v), max(hi, v)
+}
+
+// Merge folds the observations in o into s. o is left unchanged.
+func (s *Shape) Merge(o *Shape) {
+	if o == nil || o.Count == 0 {
+		return
+	}
+	if o.Integer+o.Number > 0 {
+		if s.Integer+s.Number == 0 {
+			s.MinNumber, s.MaxNumber = o.MinNumber, o.MaxNumber
+		} else {
+			s.MinNumber, s.MaxNumber = min(s.MinNumber, o.MinNumber), max(s.MaxNumber, o.MaxNumber)
+		}
+	}
+	if o.String > 0 {
+		s.MinLength, s.MaxLength = mergeRange(s.String, s.MinLength, s.MaxLength, o.MinLength, o.MaxLength)
+	}
+	if o.Array > 0 {
+		s.MinItems, s.MaxItems = mergeRange(s.Array, s.MinItems, s.MaxItems, o.MinItems, o.MaxItems)
+	}
+	s.Count += o.Count
+	s.Null += o.Null
+	s.Bool += o.Bool
+	s.Integer += o.Integer
+	s.Number += o.Number
+	s.String += o.String
+	s.Object += o.Object
+	s.Array += o.Array
+	for name, prop := range o.Properties {
+		if s.Properties == nil {
+			s.Properties = make(map[string]*Shape)
+		}
+		mine := s.Properties[name]
+		if mine == nil {
+			mine = &Shape{}
+			s.Properties[name] = mine
+		}
+		mine.Merge(prop)
+	}
+	if o.Items != nil {
+		if s.Items == nil {
+			s.Items = &Shape{}
+		}
+		s.Items.Merge(o.Items)
+	}
+}
+
+func mergeRange(n, lo, hi, olo, ohi int) (int, int) {
+	if n == 0 {
+		return olo, ohi
+	}
+	return min(lo, olo), max(hi, ohi)
+}
+
+// InferNDJSON infers the shape of newline-delimited JSON documents read from
+// r. Lines are tokenized on workers goroutines (runtime.NumCPU when workers
+// is not positive), each building its own Shape, and the results are merged.
+func InferNDJSON(r io.Reader, workers int) (*Shape, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	const batchSize = 64
+	batches := make(chan [][]byte, workers)
+	shapes := make([]*Shape, workers)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		shapes[w] = &Shape{}
+		go func(s *Shape) {
+			defer wg.Done()
+			p := NewParser(0)
+			for batch := range batches {
+				for _, line := range batch {
+					if n := len(line)/2 + 1; len(p.tokens) < n {
+						p.tokens = make([]Token, n)
+					}
+					if _, err := p.Parse(line); err != nil {
+						errs <- err
+						return
+					}
+					if err := s.Add(line, p.Tokens()); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}(shapes[w])
+	}
+
+	readErr := feedLines(r, batchSize, batches, errs)
+	close(batches)
+	wg.Wait()
+	if readErr != nil {
+		return nil, readErr
+	}
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+	merged := &Shape{}
+	for _, s := range shapes {
+		merged.Merge(s)
+	}
+	return merged, nil
+}
+
+// feedLines sends non-blank lines of r in batches until EOF or until a worker
+// reports an error.
+func feedLines(r io.Reader, batchSize int, batches chan<- [][]byte, errs chan error) error {
+	br := bufio.NewReader(r)
+	batch := make([][]byte, 0, batchSize)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			batch = append(batch, line)
+		}
+		if len(batch) == batchSize || (err != nil && len(batch) > 0) {
+			select {
+			case batches <- batch:
+			case werr := <-errs:
+				errs <- werr // Leave it for the caller.
+				return nil
+			}
+			batch = make([][]byte, 0, batchSize)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read ndjson: %w", err)
+		}
+	}
+}
+
+// JSONSchema renders the shape as a JSON Schema (draft 2020-12) document.
+func (s *Shape) JSONSchema() ([]byte, error) {
+	schema := s.schema()
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal schema: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Shape) schema() map[string]any {
+	schema := map[string]any{}
+	var types []string
+	add := func(n int, name string) {
+		if n > 0 {
+			types = append(types, name)
+		}
+	}
+	add(s.Null, "null")
+	add(s.Bool, "boolean")
+	if s.Number > 0 {
+		types = append(types, "number")
+	} else {
+		add(s.Integer, "integer")
+	}
+	add(s.String, "string")
+	add(s.Object, "object")
+	add(s.Array, "array")
+	switch len(types) {
+	case 0:
+	case 1:
+		schema["type"] = types[0]
+	default:
+		schema["type"] = types
+	}
+
+	if s.Integer+s.Number > 0 {
+		schema["minimum"] = s.MinNumber
+		schema["maximum"] = s.MaxNumber
+	}
+	if s.String > 0 {
+		schema["minLength"] = s.MinLength
+		schema["maxLength"] = s.MaxLength
+	}
+	if s.Object > 0 {
+		props := make(map[string]any, len(s.Properties))
+		var required []string
+		for name, prop := range s.Properties {
+			props[name] = prop.schema()
+			if prop.Count == s.Object {
+				required = append(required, name)
+			}
+		}
+		schema["properties"] = props
+		if len(required) > 0 {
+			slices.Sort(required)
+			schema["required"] = required
+		}
+	}
+	if s.Array > 0 {
+		schema["minItems"] = s.MinItems
+		schema["maxItems"] = s.MaxItems
+		if s.Items != nil && s.Items.Count > 0 {
+			schema["items"] = s.Items.schema()
+		}
+	}
+	return schema
+}
@@ -0,0 +1,81 @@
+package jsmngo
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const shapeInput = `{"id": 1, "name": "ab", "tags": ["x"], "score": 1.5}
+{"id": 7, "name": "héllo", "tags": [], "extra": null}
+
+{"id": -2, "name": "c", "tags": ["y", "zz", 3], "score": 2}
+`
+
+func TestShapeAdd(t *testing.T) {
+	p := NewParser(64)
+	if _, err := p.Parse([]byte(shapeInput)); err != nil {
+		t.Fatal(err)
+	}
+	var s Shape
+	if err := s.Add([]byte(shapeInput), p.Tokens()); err != nil {
+		t.Fatal(err)
+	}
+	if s.Count != 3 || s.Object != 3 {
+		t.Fatalf("expected 3 objects, got %+v", s)
+	}
+	id := s.Properties["id"]
+	if id.Integer != 3 || id.MinNumber != -2 || id.MaxNumber != 7 {
+		t.Errorf("unexpected id shape %+v", id)
+	}
+	name := s.Properties["name"]
+	if name.MinLength != 1 || name.MaxLength != 5 {
+		t.Errorf("unexpected name lengths %d..%d", name.MinLength, name.MaxLength)
+	}
+	tags := s.Properties["tags"]
+	if tags.MinItems != 0 || tags.MaxItems != 3 || tags.Items.String != 3 || tags.Items.Integer != 1 {
+		t.Errorf("unexpected tags shape %+v %+v", tags, tags.Items)
+	}
+	if s.Properties["score"].Count != 2 || s.Properties["extra"].Null != 1 {
+		t.Error("unexpected optional property counts")
+	}
+}
+
+func TestInferNDJSON(t *testing.T) {
+	for _, workers := range []int{1, 3} {
+		s, err := InferNDJSON(strings.NewReader(strings.Repeat(shapeInput, 50)), workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Count != 150 || s.Properties["score"].Count != 100 {
+			t.Errorf("workers=%d: unexpected counts %d/%d", workers, s.Count, s.Properties["score"].Count)
+		}
+		out, err := s.JSONSchema()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(out, &schema); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(schema["required"], []any{"id", "name", "tags"}) {
+			t.Errorf("unexpected required list %v", schema["required"])
+		}
+		props := schema["properties"].(map[string]any)
+		score := props["score"].(map[string]any)
+		if score["type"] != "number" || score["minimum"] != 1.5 || score["maximum"] != 2.0 {
+			t.Errorf("unexpected score schema %v", score)
+		}
+		items := props["tags"].(map[string]any)["items"].(map[string]any)
+		if !reflect.DeepEqual(items["type"], []any{"integer", "string"}) {
+			t.Errorf("unexpected tags item types %v", items["type"])
+		}
+	}
+}
+
+func TestInferNDJSONError(t *testing.T) {
+	if _, err := InferNDJSON(strings.NewReader("{\"a\": 1}\n{\"a\": tru}\n"), 2); err == nil {
+		t.Error("expected error for invalid literal")
+	}
+}
@@ -0,0 +1,157 @@
+package jsmngo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ArrayElement is one element of a top-level JSON array.
+type ArrayElement struct {
+	Index      int
+	Start, End int64  // Byte range of the element in the input.
+	Raw        []byte // Element bytes; only valid during the callback.
+}
+
+// SplitArray reads a top-level JSON array from r and calls fn with each
+// element in order. Only the current element is held in memory, so arrays
+// larger than memory can be split. The array's own syntax is checked, but
+// elements are only scanned for their extent.
+func SplitArray(r io.Reader, fn func(ArrayElement) error) error {
+	dec := NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("read array start: %w", err)
+	}
+	if tok != json.Delim('[') {
+		return errors.New("top-level value is not an array")
+	}
+	for i := 0; dec.More(); i++ {
+		raw, start, err := dec.readValue()
+		if err != nil {
+			return fmt.Errorf("read element %d: %w", i, err)
+		}
+		if err := fn(ArrayElement{Index: i, Start: start, End: start + int64(len(raw)), Raw: raw}); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("read array end: %w", err)
+	}
+	if _, err := dec.peek(); err != io.EOF {
+		if err == nil {
+			return &SyntaxError{Msg: "data after top-level array", Offset: dec.InputOffset()}
+		}
+		return err
+	}
+	return nil
+}
+
+// ShardFormat selects how ShardArray writes elements.
+type ShardFormat int
+
+const (
+	// ShardNDJSON writes one element per line.
+	ShardNDJSON ShardFormat = iota
+	// ShardArrays writes each shard as a JSON array.
+	ShardArrays
+)
+
+// shardFlushSize is how many bytes a shard buffers before handing them to
+// its writer goroutine.
+const shardFlushSize = 64 << 10
+
+// ShardArray splits the top-level JSON array read from r across shards,
+// assigning elements round-robin and writing each shard on its own goroutine.
+// Elements keep their input order within a shard. Each element is validated
+// and written compacted, so an NDJSON shard holds exactly one element per
+// line even when the input is pretty-printed. Memory use is bounded by a few
+// buffers per shard, independent of the input size.
+func ShardArray(r io.Reader, shards []io.Writer, format ShardFormat) error {
+	if len(shards) == 0 {
+		return errors.New("no shard writers")
+	}
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+		errs   = make([]error, len(shards))
+		queues = make([]chan []byte, len(shards))
+		bufs   = make([][]byte, len(shards))
+		counts = make([]int, len(shards))
+	)
+	for i, w := range shards {
+		queues[i] = make(chan []byte, 4)
+		wg.Add(1)
+		go func(i int, w io.Writer) {
+			defer wg.Done()
+			for chunk := range queues[i] {
+				if errs[i] != nil {
+					continue // Drain so the reader never blocks.
+				}
+				if _, err := w.Write(chunk); err != nil {
+					errs[i] = fmt.Errorf("write shard %d: %w", i, err)
+					failed.Store(true)
+				}
+			}
+		}(i, w)
+	}
+	flush := func(i int) {
+		if len(bufs[i]) > 0 {
+			queues[i] <- bufs[i]
+			bufs[i] = make([]byte, 0, shardFlushSize)
+		}
+	}
+
+	err := SplitArray(r, func(e ArrayElement) error {
+		if failed.Load() {
+			return errors.New("shard writer failed")
+		}
+		i := e.Index % len(shards)
+		if bufs[i] == nil {
+			bufs[i] = make([]byte, 0, shardFlushSize)
+		}
+		switch {
+		case format == ShardNDJSON:
+		case counts[i] == 0:
+			bufs[i] = append(bufs[i], '[')
+		default:
+			bufs[i] = append(bufs[i], ',')
+		}
+		buf := bytes.NewBuffer(bufs[i])
+		if err := json.Compact(buf, e.Raw); err != nil {
+			return fmt.Errorf("element %d at offset %d: %w", e.Index, e.Start, err)
+		}
+		bufs[i] = buf.Bytes()
+		if format == ShardNDJSON {
+			bufs[i] = append(bufs[i], '\n')
+		}
+		counts[i]++
+		if len(bufs[i]) >= shardFlushSize {
+			flush(i)
+		}
+		return nil
+	})
+	for i := range shards {
+		if err == nil && format == ShardArrays {
+			if counts[i] == 0 {
+				bufs[i] = append(bufs[i], '[')
+			}
+			bufs[i] = append(bufs[i], ']')
+		}
+		if err == nil {
+			flush(i)
+		}
+		close(queues[i])
+	}
+	wg.Wait()
+	for _, werr := range errs {
+		if werr != nil {
+			return werr
+		}
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package jsmngo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSplitArray(t *testing.T) {
+	in := ` [ {"a": [1, 2]}, "s,]", 3 , null, [] ] `
+	var got []string
+	err := SplitArray(iotest.OneByteReader(strings.NewReader(in)), func(e ArrayElement) error {
+		if string(e.Raw) != in[e.Start:e.End] {
+			t.Errorf("element %d: range %d-%d does not match %q", e.Index, e.Start, e.End, e.Raw)
+		}
+		got = append(got, string(e.Raw))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`{"a": [1, 2]}`, `"s,]"`, `3`, `null`, `[]`}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	for _, bad := range []string{`{"a": 1}`, `[1 2]`, `[1, 2`, `[1] [2]`} {
+		if err := SplitArray(strings.NewReader(bad), func(ArrayElement) error { return nil }); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestShardArray(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("[")
+	const n = 10000
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "name": "item%d"}`, i, i)
+	}
+	b.WriteString("]")
+
+	for _, format := range []ShardFormat{ShardNDJSON, ShardArrays} {
+		outs := make([]bytes.Buffer, 3)
+		writers := []io.Writer{&outs[0], &outs[1], &outs[2]}
+		if err := ShardArray(strings.NewReader(b.String()), writers, format); err != nil {
+			t.Fatal(err)
+		}
+		total := 0
+		for s := range outs {
+			var ids []int
+			if format == ShardArrays {
+				var elems []struct{ ID int }
+				if err := json.Unmarshal(outs[s].Bytes(), &elems); err != nil {
+					t.Fatalf("shard %d: %v", s, err)
+				}
+				for _, e := range elems {
+					ids = append(ids, e.ID)
+				}
+			} else {
+				sc := bufio.NewScanner(&outs[s])
+				for sc.Scan() {
+					var e struct{ ID int }
+					if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+						t.Fatalf("shard %d: %v", s, err)
+					}
+					ids = append(ids, e.ID)
+				}
+			}
+			for k, id := range ids {
+				if id != s+k*len(outs) {
+					t.Fatalf("format %d shard %d: element %d has id %d", format, s, k, id)
+				}
+			}
+			total += len(ids)
+		}
+		if total != n {
+			t.Errorf("format %d: expected %d elements, got %d", format, n, total)
+		}
+	}
+}
+
+func TestShardArrayCompactsElements(t *testing.T) {
+	in := "[\n  {\n    \"a\": [\n      1,\n      2\n    ],\n    \"s\": \"x y\"\n  },\n  [ ],\n  3\n]\n"
+	var ndjson, arrays bytes.Buffer
+	if err := ShardArray(strings.NewReader(in), []io.Writer{&ndjson}, ShardNDJSON); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"a\":[1,2],\"s\":\"x y\"}\n[]\n3\n"; ndjson.String() != want {
+		t.Errorf("expected %q, got %q", want, ndjson.String())
+	}
+	if err := ShardArray(strings.NewReader(in), []io.Writer{&arrays}, ShardArrays); err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"a":[1,2],"s":"x y"},[],3]`; arrays.String() != want {
+		t.Errorf("expected %q, got %q", want, arrays.String())
+	}
+
+	for _, bad := range []string{`[1, {"a" 1}]`, `[1, tru]`, `[{"a": 1,}]`, "[\"a\x01\"]"} {
+		if err := ShardArray(strings.NewReader(bad), []io.Writer{io.Discard}, ShardNDJSON); err == nil {
+			t.Errorf("%s: expected an invalid element error", bad)
+		}
+	}
+}
+
+func TestShardArrayWriterError(t *testing.T) {
+	in := "[" + strings.Repeat(`"`+strings.Repeat("x", 1000)+`",`, 500) + "1]"
+	writers := []io.Writer{io.Discard, errWriter{}, io.Discard}
+	err := ShardArray(strings.NewReader(in), writers, ShardNDJSON)
+	if !errors.Is(err, errShardTest) {
+		t.Errorf("expected writer error, got %v", err)
+	}
+}
+
+var errShardTest = errors.New("shard test failure")
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errShardTest }
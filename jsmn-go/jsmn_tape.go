@@ -0,0 +1,175 @@
+package jsmngo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Tape layout (little endian):
+//
+//	magic        [8]byte  "jsmntape"
+//	version      uint32
+//	source len   uint64
+//	source crc   uint32   CRC-32C of the JSON source
+//	token count  uint64
+//	tokens       type byte, then varints: Start delta, End-Start, Size, index-ParentIdx
+//	tape crc     uint32   CRC-32C of everything above
+const (
+	tapeMagic      = "jsmntape"
+	tapeVersion    = 1
+	tapeHeaderSize = 32
+	// TapeExt is appended to a JSON file's path by WriteTapeFile and ReadTapeFile.
+	TapeExt = ".jsmntape"
+)
+
+// ErrTapeMismatch is returned when a tape was recorded for different source bytes.
+var ErrTapeMismatch = errors.New("jsmn tape does not match source")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// WriteTape serializes tokens produced from json so ReadTape can restore them
+// later without tokenizing again.
+func WriteTape(w io.Writer, json []byte, tokens []Token) error {
+	crc := crc32.New(castagnoli)
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+
+	hdr := make([]byte, 0, tapeHeaderSize)
+	hdr = append(hdr, tapeMagic...)
+	hdr = binary.LittleEndian.AppendUint32(hdr, tapeVersion)
+	hdr = binary.LittleEndian.AppendUint64(hdr, uint64(len(json)))
+	hdr = binary.LittleEndian.AppendUint32(hdr, crc32.Checksum(json, castagnoli))
+	hdr = binary.LittleEndian.AppendUint64(hdr, uint64(len(tokens)))
+	if _, err := bw.Write(hdr); err != nil {
+		return fmt.Errorf("write tape header: %w", err)
+	}
+
+	buf := make([]byte, 0, 4*binary.MaxVarintLen64+1)
+	prev := 0
+	for i, tok := range tokens {
+		buf = append(buf[:0], byte(tok.Type))
+		buf = binary.AppendVarint(buf, int64(tok.Start-prev))
+		buf = binary.AppendVarint(buf, int64(tok.End-tok.Start))
+		buf = binary.AppendUvarint(buf, uint64(tok.Size))
+		buf = binary.AppendVarint(buf, int64(i-tok.ParentIdx))
+		if _, err := bw.Write(buf); err != nil {
+			return fmt.Errorf("write tape tokens: %w", err)
+		}
+		prev = tok.Start
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("write tape tokens: %w", err)
+	}
+	if _, err := w.Write(binary.LittleEndian.AppendUint32(nil, crc.Sum32())); err != nil {
+		return fmt.Errorf("write tape checksum: %w", err)
+	}
+	return nil
+}
+
+// ReadTape restores tokens written by WriteTape. It returns ErrTapeMismatch
+// when the tape was recorded for a different json, so callers can fall back
+// to Parse.
+func ReadTape(r io.Reader, json []byte) ([]Token, error) {
+	tape, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read tape: %w", err)
+	}
+	if len(tape) < tapeHeaderSize+4 || string(tape[:len(tapeMagic)]) != tapeMagic {
+		return nil, errors.New("not a jsmn tape")
+	}
+	body, sum := tape[:len(tape)-4], binary.LittleEndian.Uint32(tape[len(tape)-4:])
+	if crc32.Checksum(body, castagnoli) != sum {
+		return nil, errors.New("corrupt jsmn tape: checksum mismatch")
+	}
+	hdr := body[len(tapeMagic):]
+	if v := binary.LittleEndian.Uint32(hdr); v != tapeVersion {
+		return nil, fmt.Errorf("unsupported jsmn tape version %d", v)
+	}
+	if binary.LittleEndian.Uint64(hdr[4:]) != uint64(len(json)) ||
+		binary.LittleEndian.Uint32(hdr[12:]) != crc32.Checksum(json, castagnoli) {
+		return nil, ErrTapeMismatch
+	}
+	count := binary.LittleEndian.Uint64(hdr[16:])
+	data := body[tapeHeaderSize:]
+	if count > uint64(len(data))/5 { // Every token takes at least five bytes.
+		return nil, errors.New("corrupt jsmn tape: bad token count")
+	}
+
+	tokens := make([]Token, count)
+	prev := 0
+	for i := range tokens {
+		var f [4]int64
+		if len(data) == 0 || data[0] > byte(Primitive) {
+			return nil, errors.New("corrupt jsmn tape: bad token type")
+		}
+		typ := TokenType(data[0])
+		data = data[1:]
+		for j := range f {
+			var n int
+			if j == 2 {
+				var u uint64
+				u, n = binary.Uvarint(data)
+				f[j] = int64(u)
+			} else {
+				f[j], n = binary.Varint(data)
+			}
+			if n <= 0 {
+				return nil, errors.New("corrupt jsmn tape: truncated token")
+			}
+			data = data[n:]
+		}
+		tok := Token{
+			Type:      typ,
+			Start:     prev + int(f[0]),
+			Size:      int(f[2]),
+			ParentIdx: i - int(f[3]),
+		}
+		tok.End = tok.Start + int(f[1])
+		if tok.Start < 0 || tok.End < tok.Start || tok.End > len(json) || tok.ParentIdx < -1 || tok.ParentIdx >= i {
+			return nil, fmt.Errorf("corrupt jsmn tape: token %d out of range", i)
+		}
+		tokens[i] = tok
+		prev = tok.Start
+	}
+	if len(data) != 0 {
+		return nil, errors.New("corrupt jsmn tape: trailing data")
+	}
+	return tokens, nil
+}
+
+// WriteTapeFile writes the tape for the JSON file at jsonPath next to it,
+// at jsonPath+TapeExt. The tape is replaced atomically.
+func WriteTapeFile(jsonPath string, json []byte, tokens []Token) error {
+	f, err := os.CreateTemp(filepath.Dir(jsonPath), filepath.Base(jsonPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create tape file: %w", err)
+	}
+	defer os.Remove(f.Name()) // No-op after a successful rename.
+	if err := WriteTape(f, json, tokens); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close tape file: %w", err)
+	}
+	if err := os.Rename(f.Name(), jsonPath+TapeExt); err != nil {
+		return fmt.Errorf("rename tape file: %w", err)
+	}
+	return nil
+}
+
+// ReadTapeFile loads the tape stored next to the JSON file at jsonPath and
+// validates it against json, the file's current contents.
+func ReadTapeFile(jsonPath string, json []byte) ([]Token, error) {
+	f, err := os.Open(jsonPath + TapeExt)
+	if err != nil {
+		return nil, fmt.Errorf("open tape file: %w", err)
+	}
+	defer f.Close()
+	return ReadTape(bufio.NewReader(f), json)
+}
@@ -0,0 +1,69 @@
+package jsmngo
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestTapeRoundTrip(t *testing.T) {
+	json := []byte(`{"key": "value", "arr": [1, 2, {"n": null}], "s": "a\"b"}`)
+	p := NewParser(32)
+	if _, err := p.Parse(json); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteTape(&buf, json, p.Tokens()); err != nil {
+		t.Fatal(err)
+	}
+	tape := buf.Bytes()
+	tokens, err := ReadTape(bytes.NewReader(tape), json)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tokens, p.Tokens()) {
+		t.Errorf("tokens differ after round trip:\n%v\n%v", tokens, p.Tokens())
+	}
+
+	changed := bytes.Replace(json, []byte("value"), []byte("VALUE"), 1)
+	if _, err := ReadTape(bytes.NewReader(tape), changed); !errors.Is(err, ErrTapeMismatch) {
+		t.Errorf("expected ErrTapeMismatch, got %v", err)
+	}
+	corrupt := slices.Clone(tape)
+	corrupt[tapeHeaderSize+1] ^= 0xff
+	if _, err := ReadTape(bytes.NewReader(corrupt), json); err == nil {
+		t.Error("expected error for corrupt tape")
+	}
+	if _, err := ReadTape(bytes.NewReader(tape[:20]), json); err == nil {
+		t.Error("expected error for truncated tape")
+	}
+}
+
+func TestTapeFile(t *testing.T) {
+	json := []byte(`[1, 2, 3]`)
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, json, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := NewParser(4)
+	if _, err := p.Parse(json); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTapeFile(path, json, p.Tokens()); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := ReadTapeFile(path, json)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tokens, p.Tokens()) {
+		t.Errorf("tokens differ after file round trip")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 2 {
+		t.Errorf("expected json and tape only, got %d entries", len(entries))
+	}
+}
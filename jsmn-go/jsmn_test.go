@@ -2,6 +2,9 @@ package jsmngo
 
 import (
 	"bytes"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -63,3 +66,36 @@ func TestParseStreamDecoder(t *testing.T) {
 		t.Errorf("expected 3 tokens, got %d", len(tokens))
 	}
 }
+
+func TestParseParallelSplitsObjects(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(" {")
+	for i := range 500 {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, `"k%d": {"v": [%d, "}"]}`, i, i)
+	}
+	b.WriteString("} ")
+	json := []byte(b.String())
+	want, err := parseSequential(json, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{1, 2, 4} {
+		got, err := parseParallel(json, 10000, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%d workers: tokens differ from Parse", workers)
+		}
+	}
+	for _, bad := range []string{`[1, 2, {"a": 1]`, `[1, 2], 3`, `[1, "2]`} {
+		json := []byte(bad + strings.Repeat(" ", 600))
+		_, err := parseParallel(json, 100, 2)
+		if _, seqErr := parseSequential(json, 100); (err == nil) != (seqErr == nil) {
+			t.Errorf("%s: got %v, expected the sequential result %v", bad, err, seqErr)
+		}
+	}
+}